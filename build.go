@@ -277,6 +277,13 @@ func (sf *sortedFunctionsByFunctionality) setRouteFunction(gd tempDir, leafPath
 		return err
 	}
 
+	expVars, err := getExportedVars(gd.FilePath)
+	if err != nil {
+		return err
+	}
+
+	fmtCache := determineCache(expVars, pkName)
+
 	for expFn, expT := range expFns {
 		err := determineFunctionDefinition(expT)
 		if err != nil {
@@ -306,9 +313,10 @@ func (sf *sortedFunctionsByFunctionality) setRouteFunction(gd tempDir, leafPath
 		 *	  Path    string
 		 *	  Handler interface{}
 		 *	  ParamType
+		 *	  Cache   *utils.Cache
 		 * }
 		 **/
-		fnProps := fmt.Sprintf(`{"%s/%s", %s.%s, %d},`, leafPath, expFnPath, pkName, expFn, fnParams)
+		fnProps := fmt.Sprintf(`{"%s/%s", %s.%s, %d, %s},`, leafPath, expFnPath, pkName, expFn, fnParams, fmtCache)
 
 		sf.addToSortedFunctions(fnType, fnProps, expFn, "", "")
 
@@ -335,6 +343,8 @@ func (sf *sortedFunctionsByFunctionality) setPageFunction(gd tempDir, leafPath s
 	}
 
 	fmtVars := determineVars(expVars, pkName)
+	fmtParams := determineStaticParams(expVars, pkName)
+	fmtCache := determineCache(expVars, pkName)
 
 	for expFn, expT := range expFns {
 
@@ -362,10 +372,13 @@ func (sf *sortedFunctionsByFunctionality) setPageFunction(gd tempDir, leafPath s
 		 *	  Path    string
 		 *	  Handler interface{}
 		 *	  ParamType
+		 *	  Metadata     []string
+		 *	  StaticParams []map[string]string
+		 *	  Cache        *utils.Cache
 		 * }
 		 **/
 
-		fnProps := fmt.Sprintf(`{"%s", %s.%s, %d, %s},`, leafPath, pkName, expFn, fnParams, fmtVars)
+		fnProps := fmt.Sprintf(`{"%s", %s.%s, %d, %s, %s, %s},`, leafPath, pkName, expFn, fnParams, fmtVars, fmtParams, fmtCache)
 		fmt.Println("FNPROPS", fnProps)
 
 		sf.addToSortedFunctions(fnType, fnProps, expFn, "", "")
@@ -390,6 +403,8 @@ func (sf *sortedFunctionsByFunctionality) setIndexFunction(gd tempDir, leafPath
 	}
 
 	fmtVars := determineVars(expVars, pkName)
+	fmtBoost := determineBoost(expVars, pkName)
+	fmtNested := determineNested(expVars, pkName)
 
 	for expFn, expT := range expFns {
 
@@ -436,9 +451,12 @@ func (sf *sortedFunctionsByFunctionality) setIndexFunction(gd tempDir, leafPath
 		 *	  Handler interface{}
 		 *	  ParamType
 		 *	  HandleType
+		 *	  Metadata []string
+		 *	  Boost    *utils.Boost
+		 *	  Nested   bool
 		 * }
 		 **/
-		fnProps := fmt.Sprintf(`{"%s", %s.%s, %d, %d, %s}`, indexPath, pkName, expFn, fnParams, fnType, fmtVars)
+		fnProps := fmt.Sprintf(`{"%s", %s.%s, %d, %d, %s, %s, %s}`, indexPath, pkName, expFn, fnParams, fnType, fmtVars, fmtBoost, fmtNested)
 
 		sf.addToSortedFunctions(fnType, fnProps, expFn, indexPath, leafPath)
 
@@ -535,6 +553,7 @@ func determineVars(expVars map[string]varType, pkName string) string {
 		switch name {
 		case METADATA:
 			metadata = fmt.Sprintf("%s.%s", pkName, METADATA)
+		case PARAMS, BOOST, NESTED, CACHE:
 		default:
 			fmt.Println("WHAT HAPPENED HERE")
 		}
@@ -543,6 +562,38 @@ func determineVars(expVars map[string]varType, pkName string) string {
 	return fmtVars
 }
 
+// determineBoost returns the Boost var of an index, the target & swap of the boosted navigations between its pages
+func determineBoost(expVars map[string]varType, pkName string) string {
+	if _, ok := expVars[BOOST]; ok {
+		return fmt.Sprintf("&%s.%s", pkName, BOOST)
+	}
+	return "nil"
+}
+
+// determineNested returns the Nested var of an index, which is rendered inside its parent index if true
+func determineNested(expVars map[string]varType, pkName string) string {
+	if _, ok := expVars[NESTED]; ok {
+		return fmt.Sprintf("%s.%s", pkName, NESTED)
+	}
+	return "false"
+}
+
+// determineCache returns the Cache var of a page or route, its Cache-Control policy
+func determineCache(expVars map[string]varType, pkName string) string {
+	if _, ok := expVars[CACHE]; ok {
+		return fmt.Sprintf("&%s.%s", pkName, CACHE)
+	}
+	return "nil"
+}
+
+// determineStaticParams returns the StaticParams var of a page, used to export its slug paths
+func determineStaticParams(expVars map[string]varType, pkName string) string {
+	if _, ok := expVars[PARAMS]; ok {
+		return fmt.Sprintf("%s.%s", pkName, PARAMS)
+	}
+	return "nil"
+}
+
 // only return USABLE exported variables
 func getExportedVars(path string) (map[string]varType, error) {
 	node, err := getAstVals(path)
@@ -821,7 +872,7 @@ func dirPostfixSuffixRemoval(path string) string {
 	* So we want to take the file path -> _example_ and add it to the filepath as "/{example}"
 	* Not sure the effects of this yet in current structure
 	* NOTE
-	* Have to use name of folder cuz you can access this from request handler -> slug := mux.Vars(r)["example"]
+	* Have to use name of folder cuz you can access this from request handler -> slug := temporary.PathParam(r, "example")
 	* ISSUE
 	* So it seems the `templ generate` command ignores any dirs with "_" prefix. So templs in slug dirs will be ignored?
 	* Can specify dirs -> templ generate -f /home/caleb/go/personal/src/app/_test/test.templ
@@ -0,0 +1,139 @@
+package temporary
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"sort"
+
+	"calebsideras.com/temporary/temporary/utils"
+	"github.com/a-h/templ"
+)
+
+// staticOutput is a pre-rendered file & its pre-compressed sibling, held in memory by Run()
+type staticOutput struct {
+	ManifestEntry
+	Content []byte
+	Gzip    []byte
+}
+
+// staticCache serves the pre-rendered files described by the render manifest.
+// outputs is loaded once at startup & never modified, so it's safe to share between requests.
+// In dev mode nothing is cached, every get() reloads the manifest & the file from fsys
+type staticCache struct {
+	fsys    fs.FS
+	dev     bool
+	outputs map[string]*staticOutput
+}
+
+// loadStaticCache loads the render manifest & every file it references from @fsys, failing if any of them is missing
+func loadStaticCache(fsys fs.FS, dev bool) (*staticCache, error) {
+	manifest, err := loadManifest(fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	err = manifest.verify(fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	cache := &staticCache{fsys: fsys, dev: dev, outputs: make(map[string]*staticOutput)}
+	if dev {
+		return cache, nil
+	}
+
+	for file, entry := range manifest.entries() {
+		output, err := readStaticOutput(fsys, entry)
+		if err != nil {
+			return nil, err
+		}
+		cache.outputs[file] = output
+	}
+	return cache, nil
+}
+
+// get returns the pre-rendered @file e.g. /docs/page.html
+func (c *staticCache) get(file string) (*staticOutput, error) {
+	if !c.dev {
+		output, ok := c.outputs[file]
+		if !ok {
+			return nil, fmt.Errorf("%s is not in %s", file, MANIFEST_FILE)
+		}
+		return output, nil
+	}
+
+	manifest, err := loadManifest(c.fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	entry, ok := manifest.entries()[file]
+	if !ok {
+		return nil, fmt.Errorf("%s is not in %s", file, MANIFEST_FILE)
+	}
+	return readStaticOutput(c.fsys, entry)
+}
+
+// layout returns the pre-rendered index.html of @indexPath, split where its pages are inserted
+func (c *staticCache) layout(indexPath string) (indexLayout, error) {
+	output, err := c.get(filepath.Join(indexPath, INDEX_OUT_FILE))
+	if err != nil {
+		return indexLayout{}, err
+	}
+	return splitIndexLayout(output.Content)
+}
+
+// suspense returns the runtime suspense boundaries of the pre-rendered files, sorted by ID
+func (c *staticCache) suspense() ([]utils.SuspenseBoundary, error) {
+	manifest, err := loadManifest(c.fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	boundaries := make(map[string]utils.SuspenseBoundary)
+	for _, entry := range manifest.entries() {
+		for _, boundary := range entry.Suspense {
+			boundaries[boundary.ID()] = boundary
+		}
+	}
+
+	ids := make([]string, 0, len(boundaries))
+	for id := range boundaries {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	sorted := make([]utils.SuspenseBoundary, 0, len(ids))
+	for _, id := range ids {
+		sorted = append(sorted, boundaries[id])
+	}
+	return sorted, nil
+}
+
+func readStaticOutput(fsys fs.FS, entry ManifestEntry) (*staticOutput, error) {
+	content, err := fs.ReadFile(fsys, outputPath(entry.File))
+	if err != nil {
+		return nil, err
+	}
+
+	output := &staticOutput{ManifestEntry: entry, Content: content}
+
+	if entry.hasEncoding(GZIP_ENCODING) {
+		output.Gzip, err = fs.ReadFile(fsys, outputPath(entry.File+GZ_EXT))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return output, nil
+}
+
+// component writes the pre-rendered bytes as they are, e.g. as the children of a dynamic index
+func (o *staticOutput) component() templ.Component {
+	return templ.ComponentFunc(func(ctx context.Context, w io.Writer) error {
+		_, err := w.Write(o.Content)
+		return err
+	})
+}
@@ -0,0 +1,79 @@
+package temporary
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	GZIP_ENCODING = "gzip"
+
+	// responses smaller than this aren't worth compressing on the fly
+	DEFAULT_GZIP_MIN_SIZE = 1024
+)
+
+func gzipBytes(content []byte) ([]byte, error) {
+	var buffer bytes.Buffer
+
+	gw, err := gzip.NewWriterLevel(&buffer, gzip.BestCompression)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = gw.Write(content)
+	if err != nil {
+		return nil, err
+	}
+
+	err = gw.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// acceptsGzip reports whether the Accept-Encoding of @r allows gzip, i.e. gzip or * without q=0.
+// An explicit gzip entry takes precedence over *, so "gzip;q=0, *" refuses gzip
+func acceptsGzip(r *http.Request) bool {
+	gzip, wildcard := -1.0, -1.0
+	for _, header := range r.Header.Values("Accept-Encoding") {
+		for _, part := range strings.Split(header, ",") {
+			coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+			coding = strings.ToLower(strings.TrimSpace(coding))
+			if coding != GZIP_ENCODING && coding != "*" {
+				continue
+			}
+
+			q := 1.0
+			if name, value, ok := strings.Cut(strings.TrimSpace(params), "="); ok && strings.TrimSpace(name) == "q" {
+				parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+				if err == nil {
+					q = parsed
+				}
+			}
+
+			if coding == GZIP_ENCODING {
+				gzip = q
+			} else {
+				wildcard = q
+			}
+		}
+	}
+
+	if gzip >= 0 {
+		return gzip > 0
+	}
+	return wildcard > 0
+}
+
+// encodingETag derives a distinct etag per content encoding, e.g. "abc" -> "abc-gzip"
+func encodingETag(eTag string, encoding string) string {
+	if encoding == "" {
+		return eTag
+	}
+	return strings.TrimSuffix(eTag, `"`) + "-" + encoding + `"`
+}
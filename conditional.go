@@ -0,0 +1,77 @@
+package temporary
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+// notModified evaluates the preconditions of @r against the current @eTag & @lastModified (zero if unknown), as per RFC 9110 13.2.2.
+// If-Modified-Since is ignored when If-None-Match is present, both only apply to GET & HEAD
+func notModified(r *http.Request, eTag string, lastModified time.Time) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+
+	if inm := r.Header.Values("If-None-Match"); len(inm) > 0 {
+		return eTagMatches(strings.Join(inm, ","), eTag)
+	}
+
+	ims := r.Header.Get("If-Modified-Since")
+	if ims == "" || lastModified.IsZero() {
+		return false
+	}
+
+	since, err := http.ParseTime(ims)
+	if err != nil {
+		return false
+	}
+
+	// Last-Modified has a precision of one second
+	return !lastModified.Truncate(time.Second).After(since)
+}
+
+// eTagMatches reports whether the If-None-Match @header, i.e. "*" or a list of entity-tags, matches @eTag.
+// Uses the weak comparison of RFC 9110 8.8.3.2, W/"x" matches "x"
+func eTagMatches(header string, eTag string) bool {
+	if eTag == "" {
+		return false
+	}
+
+	eTag = strings.TrimPrefix(eTag, "W/")
+
+	for header != "" {
+		header = strings.TrimLeft(header, " \t,")
+		if header == "" {
+			break
+		}
+
+		if header[0] == '*' {
+			return true
+		}
+
+		header = strings.TrimPrefix(header, "W/")
+		if header == "" || header[0] != '"' {
+			// malformed, skip to the next entity-tag
+			_, header, _ = strings.Cut(header, ",")
+			continue
+		}
+
+		end := strings.IndexByte(header[1:], '"')
+		if end < 0 {
+			return false
+		}
+
+		if header[:end+2] == eTag {
+			return true
+		}
+		header = header[end+2:]
+	}
+	return false
+}
+
+func setLastModified(w http.ResponseWriter, lastModified time.Time) {
+	if !lastModified.IsZero() {
+		w.Header().Set("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+}
@@ -0,0 +1,143 @@
+package temporary
+
+import (
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"runtime"
+	"strconv"
+	"time"
+
+	"calebsideras.com/temporary/temporary/utils"
+	"github.com/a-h/templ"
+)
+
+// Config holds the user defined options used by Render() & Run()
+type Config struct {
+	// RenderWorkers is the max number of static files rendered at once, defaults to runtime.NumCPU()
+	RenderWorkers int
+	// ForceRender re-renders every static file, even if its fingerprint is unchanged since the last Render()
+	ForceRender bool
+	// ExportDir, if set, makes Render() also export the site as plain files deployable without a Go server
+	ExportDir string
+	// GzipMinSize is the min size in bytes of a dynamic response compressed on the fly, defaults to DEFAULT_GZIP_MIN_SIZE
+	GzipMinSize int
+	// Minify enables the HTML minifier for every static & dynamic output
+	Minify bool
+	// MinifyPaths overrides Minify for the given index, page & route paths e.g. {"/docs": false}.
+	// Streamed responses (see Stream) aren't minified, only the pre-rendered layout shell they're written in
+	MinifyPaths map[string]bool
+	// Stream writes dynamic pages & routes as they are rendered, flushing the layout shell first, without minifying them. See stream.go.
+	// A panic once the shell is flushed can't answer 500: ErrorComponent is appended to what was sent & the response aborted,
+	// so htmx swaps nothing
+	Stream bool
+	// StreamPaths overrides Stream for the given page & route paths
+	StreamPaths map[string]bool
+	// DevMode makes Run() reload pre-rendered files from OutputFS on every request instead of caching them at startup
+	DevMode bool
+	// OutputFS holds the pre-rendered output read by Run(), defaults to HTML_OUT_DIR on disk.
+	// To ship a single binary, embed HTML_OUT_DIR after Render() & pass it through fs.Sub e.g. fs.Sub(embedded, "static/html")
+	OutputFS fs.FS
+	// ShutdownTimeout is the max time Serve() waits for in-flight requests once its context is cancelled, defaults to DEFAULT_SHUTDOWN_TIMEOUT
+	ShutdownTimeout time.Duration
+	// Logger receives the access logs & errors of Run(), defaults to slog.Default()
+	Logger *slog.Logger
+	// ErrorComponent renders the body of an error response, e.g. a 500 after a handler panic, defaults to DefaultErrorComponent.
+	// htmx only swaps it in with HTMX_ERROR_SCRIPT
+	ErrorComponent func(r *http.Request, status int) templ.Component
+	// SuspenseKey signs the URLs of utils.SuspenseRoute boundaries, replicas behind the same load balancer must share it
+	SuspenseKey []byte
+	// CachePaths overrides the Cache var of the given page & route paths, e.g. {"/docs": {MaxAge: time.Hour}}
+	CachePaths map[string]utils.Cache
+	// BasePath is the prefix the handler is mounted under e.g. by http.StripPrefix, stripped from HX-Current-URL to find its route
+	BasePath string
+	// SuspenseTTL is how long a suspense URL stays valid, defaults to utils.DEFAULT_SUSPENSE_TTL
+	SuspenseTTL time.Duration
+}
+
+const DEFAULT_SHUTDOWN_TIMEOUT = 10 * time.Second
+
+// SetConfig replaces the config used by Render() & Run()
+func (t *Temp) SetConfig(config Config) {
+	t.config = config
+}
+
+func (c Config) renderWorkers() int {
+	if c.RenderWorkers > 0 {
+		return c.RenderWorkers
+	}
+	return runtime.NumCPU()
+}
+
+func (c Config) outputFS() fs.FS {
+	if c.OutputFS != nil {
+		return c.OutputFS
+	}
+	return outputFS()
+}
+
+func (c Config) logger() *slog.Logger {
+	if c.Logger != nil {
+		return c.Logger
+	}
+	return slog.Default()
+}
+
+func (c Config) errorComponent(r *http.Request, status int) templ.Component {
+	if c.ErrorComponent != nil {
+		return c.ErrorComponent(r, status)
+	}
+	return DefaultErrorComponent(r, status)
+}
+
+func (c Config) shutdownTimeout() time.Duration {
+	if c.ShutdownTimeout > 0 {
+		return c.ShutdownTimeout
+	}
+	return DEFAULT_SHUTDOWN_TIMEOUT
+}
+
+func (c Config) gzipMinSize() int {
+	if c.GzipMinSize > 0 {
+		return c.GzipMinSize
+	}
+	return DEFAULT_GZIP_MIN_SIZE
+}
+
+func (c Config) minify(path string) bool {
+	if minify, ok := c.MinifyPaths[path]; ok {
+		return minify
+	}
+	return c.Minify
+}
+
+func (c Config) stream(path string) bool {
+	if stream, ok := c.StreamPaths[path]; ok {
+		return stream
+	}
+	return c.Stream
+}
+
+// cacheControl returns the Cache-Control header of @path: its CachePaths entry, else its Cache var @cache, else DEFAULT_CACHE_CONTROL
+func (c Config) cacheControl(path string, cache *utils.Cache) string {
+	if policy, ok := c.CachePaths[path]; ok {
+		return policy.String()
+	}
+	if cache != nil {
+		return cache.String()
+	}
+	return DEFAULT_CACHE_CONTROL
+}
+
+// minifyHTML minifies @content if enabled for @path
+func (c Config) minifyHTML(path string, content []byte) []byte {
+	if !c.minify(path) {
+		return content
+	}
+	return utils.MinifyHTML(content)
+}
+
+// minifyKey is part of the fingerprint of a static output, so toggling the minifier re-renders it
+func (c Config) minifyKey(path string) string {
+	return "minify=" + strconv.FormatBool(c.minify(path))
+}
@@ -59,52 +59,52 @@ var PathToIndex = map[string]string{
 }
 
 var Index = map[string]IndexProps{
-	"/" : {"/", app.Index_, 0, 1, []string{}},
+	"/" : {"/", app.Index_, 0, 1, []string{}, nil, false},
 }
 
 var PageStatic = []PageProps{
-	{"/docs/index", docs_index.Page_, 0, []string{}},
-	{"/docs/pages/static-pages", docs_pages_staticpages.Page_, 0, []string{}},
-	{"/docs/routing/defining-routes", docs_routingfundamentals.Page_, 0, []string{}},
-	{"/docs/routing/hx-boost", docs_routing_hxboost.Page_, 0, []string{}},
-	{"/docs/pages", docs_pages.Page_, 0, []string{}},
-	{"/docs/pages/templ", docs_pages_templ.Page_, 0, []string{}},
-	{"/docs/routing/caching", docs_routing_caching.Page_, 0, []string{}},
-	{"/docs/routing/dynamic-routes", docs_routing_dynamicrouting.Page_, 0, []string{}},
-	{"/docs/routing/static-pages-and-routes", docs_routing_staticpagesandroutes.Page_, 0, []string{}},
-	{"/docs/index/templ", docs_index_templ.Page_, 0, []string{}},
-	{"/docs", docs_introduction.Page_, 0, []string{}},
-	{"/docs/project-structure", docs_projectstructure.Page_, 0, []string{}},
-	{"/docs/routing/pages-and-index", docs_routing_pagesandindex.Page_, 0, []string{}},
-	{"/examples/static-render", examples_staticrender.Page_, 0, examples_staticrender.Metadata},
-	{"/examples/todo", examples_todo.Page_, 0, examples_todo.Metadata},
-	{"/docs/installation", docs_installation.Page_, 0, []string{}},
-	{"/docs/routing/suspense", docs_routing_suspense.Page_, 0, []string{}},
-	{"/docs/routing", docs_routing.Page_, 0, []string{}},
-	{"/docs/routing/dependency-injection", docs_routing_dependencyinjecton.Page_, 0, []string{}},
-	{"/", home.Page_, 0, home.Metadata},
+	{"/docs/index", docs_index.Page_, 0, []string{}, nil, nil},
+	{"/docs/pages/static-pages", docs_pages_staticpages.Page_, 0, []string{}, nil, nil},
+	{"/docs/routing/defining-routes", docs_routingfundamentals.Page_, 0, []string{}, nil, nil},
+	{"/docs/routing/hx-boost", docs_routing_hxboost.Page_, 0, []string{}, nil, nil},
+	{"/docs/pages", docs_pages.Page_, 0, []string{}, nil, nil},
+	{"/docs/pages/templ", docs_pages_templ.Page_, 0, []string{}, nil, nil},
+	{"/docs/routing/caching", docs_routing_caching.Page_, 0, []string{}, nil, nil},
+	{"/docs/routing/dynamic-routes", docs_routing_dynamicrouting.Page_, 0, []string{}, nil, nil},
+	{"/docs/routing/static-pages-and-routes", docs_routing_staticpagesandroutes.Page_, 0, []string{}, nil, nil},
+	{"/docs/index/templ", docs_index_templ.Page_, 0, []string{}, nil, nil},
+	{"/docs", docs_introduction.Page_, 0, []string{}, nil, nil},
+	{"/docs/project-structure", docs_projectstructure.Page_, 0, []string{}, nil, nil},
+	{"/docs/routing/pages-and-index", docs_routing_pagesandindex.Page_, 0, []string{}, nil, nil},
+	{"/examples/static-render", examples_staticrender.Page_, 0, examples_staticrender.Metadata, nil, nil},
+	{"/examples/todo", examples_todo.Page_, 0, examples_todo.Metadata, nil, nil},
+	{"/docs/installation", docs_installation.Page_, 0, []string{}, nil, nil},
+	{"/docs/routing/suspense", docs_routing_suspense.Page_, 0, []string{}, nil, nil},
+	{"/docs/routing", docs_routing.Page_, 0, []string{}, nil, nil},
+	{"/docs/routing/dependency-injection", docs_routing_dependencyinjecton.Page_, 0, []string{}, nil, nil},
+	{"/", home.Page_, 0, home.Metadata, nil, nil},
 }
 
 var PageDynamic = []PageProps{
-	{"/examples/metadata", metadata.Page, 0, metadata.Metadata},
-	{"/examples/suspense", examples_suspense.Page, 0, examples_suspense.Metadata},
-	{"/examples/{slug}/dynamic-routes", examples_dynamicroutes.Page, 2, examples_dynamicroutes.Metadata},
-	{"/examples/dependency-injection", examples_dependencyinjection.Page, 1, examples_dependencyinjection.Metadata},
+	{"/examples/metadata", metadata.Page, 0, metadata.Metadata, nil, nil},
+	{"/examples/suspense", examples_suspense.Page, 0, examples_suspense.Metadata, nil, nil},
+	{"/examples/{slug}/dynamic-routes", examples_dynamicroutes.Page, 2, examples_dynamicroutes.Metadata, nil, nil},
+	{"/examples/dependency-injection", examples_dependencyinjection.Page, 1, examples_dependencyinjection.Metadata, nil, nil},
 }
 
 var RouteStatic = []RouteProps{
-	{"/examples/suspense/code", examples_suspense.Code_, 0},
-	{"/examples/{slug}/dynamic-routes/code", examples_dynamicroutes.Code_, 0},
-	{"/examples/dependency-injection/code", examples_dependencyinjection.Code_, 0},
-	{"/examples/static-render/example", examples_staticrender.Example_, 0},
-	{"/examples/static-render/code", examples_staticrender.Code_, 0},
-	{"/examples/todo/example", examples_todo.Example_, 0},
-	{"/examples/todo/code", examples_todo.Code_, 0},
+	{"/examples/suspense/code", examples_suspense.Code_, 0, nil},
+	{"/examples/{slug}/dynamic-routes/code", examples_dynamicroutes.Code_, 0, nil},
+	{"/examples/dependency-injection/code", examples_dependencyinjection.Code_, 0, nil},
+	{"/examples/static-render/example", examples_staticrender.Example_, 0, nil},
+	{"/examples/static-render/code", examples_staticrender.Code_, 0, nil},
+	{"/examples/todo/example", examples_todo.Example_, 0, nil},
+	{"/examples/todo/code", examples_todo.Code_, 0, nil},
 }
 
 var RouteDynamic = []RouteProps{
-	{"/examples/suspense/example", examples_suspense.Example, 0},
-	{"/examples/{slug}/dynamic-routes/example", examples_dynamicroutes.Example, 2},
-	{"/examples/dependency-injection/example", examples_dependencyinjection.Example, 1},
-	{"/examples/todo/add-task", examples_todo.AddTask, 2},
+	{"/examples/suspense/example", examples_suspense.Example, 0, nil},
+	{"/examples/{slug}/dynamic-routes/example", examples_dynamicroutes.Example, 2, nil},
+	{"/examples/dependency-injection/example", examples_dependencyinjection.Example, 1, nil},
+	{"/examples/todo/add-task", examples_todo.AddTask, 2, nil},
 }
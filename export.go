@@ -0,0 +1,358 @@
+package temporary
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"calebsideras.com/temporary/temporary/utils"
+)
+
+var (
+	anchorTagRegex = regexp.MustCompile(`<a\s[^>]*>`)
+	hrefAttrRegex  = regexp.MustCompile(`\shref=(?:"([^"]*)"|'([^']*)'|([^\s"'>]+))`)
+	slugRegex      = regexp.MustCompile(`\{([^}]+)\}`)
+)
+
+// exportedPage is a page written by export(), its links are rewritten before being written
+type exportedPage struct {
+	Path     string
+	Full     []byte
+	Body     []byte
+	Suspense []utils.SuspenseBoundary
+}
+
+// export writes the site rendered by Render() to @outDir as plain files, deployable to any static host:
+//
+//	/path/index.html       - full page
+//	/path/index-body.html  - page body, fetched by the hx-boost links of the exported pages
+//	/route/index.html      - static route
+//	/_temporary/suspense/* - runtime suspense boundaries, resolved once at export time
+//
+// The assets in HTML_SERVE_PATH are copied over. Dynamic pages & routes are reported as unexportable
+func (g *Temp) export(outDir string) error {
+
+	fmt.Println("--------------------------EXPORTING STATIC SITE--------------------------")
+
+	fsys := outputFS()
+
+	matcher, err := newRouteMatcher(PathToIndex, "")
+	if err != nil {
+		return newRenderError([]error{err})
+	}
+
+	var pages []exportedPage
+	var unexportable []string
+	var errs []error
+
+	for _, pageProps := range PageStatic {
+		indexPath, ok := PathToIndex[pageProps.Path]
+		if !ok {
+			errs = append(errs, &RenderFailure{pageProps.Path, EXPORT_OUT_FILE, fmt.Errorf("could not find an index for path")})
+			continue
+		}
+
+		if Index[indexPath].HandleType != IndexRender {
+			unexportable = append(unexportable, fmt.Sprintf("%s (dynamic index: %s)", pageProps.Path, indexPath))
+			continue
+		}
+
+		if !slugRegex.MatchString(pageProps.Path) {
+			full, err := fs.ReadFile(fsys, outputPath(filepath.Join(pageProps.Path, PAGE_OUT_FILE)))
+			if err != nil {
+				errs = append(errs, &RenderFailure{pageProps.Path, EXPORT_OUT_FILE, err})
+				continue
+			}
+
+			body, err := fs.ReadFile(fsys, outputPath(filepath.Join(pageProps.Path, PAGE_BODY_OUT_FILE_W_METADATA)))
+			if err != nil {
+				errs = append(errs, &RenderFailure{pageProps.Path, EXPORT_BODY_OUT_FILE, err})
+				continue
+			}
+
+			pages = append(pages, exportedPage{pageProps.Path, full, body, nil})
+			continue
+		}
+
+		if len(pageProps.StaticParams) == 0 {
+			unexportable = append(unexportable, fmt.Sprintf("%s (no %s)", pageProps.Path, PARAMS))
+			continue
+		}
+
+		for _, params := range pageProps.StaticParams {
+			page, err := g.exportSlugPage(fsys, pageProps, indexPath, params)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			pages = append(pages, page)
+		}
+	}
+
+	for _, pageProps := range PageDynamic {
+		unexportable = append(unexportable, fmt.Sprintf("%s (dynamic page)", pageProps.Path))
+	}
+
+	for _, routeProps := range RouteDynamic {
+		unexportable = append(unexportable, fmt.Sprintf("%s (dynamic route)", routeProps.Path))
+	}
+
+	exported := utils.NewStringSet()
+	for _, page := range pages {
+		exported.Add(page.Path)
+	}
+
+	for _, page := range pages {
+		fmt.Println("Directory:", page.Path)
+
+		err := writeFileTo(outDir, filepath.Join(page.Path, EXPORT_OUT_FILE), rewriteBoostLinks(page.Full, exported, matcher))
+		if err != nil {
+			errs = append(errs, &RenderFailure{page.Path, EXPORT_OUT_FILE, err})
+		}
+
+		err = writeFileTo(outDir, filepath.Join(page.Path, EXPORT_BODY_OUT_FILE), rewriteBoostLinks(page.Body, exported, matcher))
+		if err != nil {
+			errs = append(errs, &RenderFailure{page.Path, EXPORT_BODY_OUT_FILE, err})
+		}
+	}
+
+	for _, routeProps := range RouteStatic {
+		if slugRegex.MatchString(routeProps.Path) {
+			unexportable = append(unexportable, fmt.Sprintf("%s (slug route)", routeProps.Path))
+			continue
+		}
+
+		fmt.Println("Directory:", routeProps.Path)
+
+		content, err := fs.ReadFile(fsys, outputPath(filepath.Join(routeProps.Path, ROUTE_OUT_FILE)))
+		if err == nil {
+			err = writeFileTo(outDir, filepath.Join(routeProps.Path, EXPORT_OUT_FILE), rewriteBoostLinks(content, exported, matcher))
+		}
+		if err != nil {
+			errs = append(errs, &RenderFailure{routeProps.Path, EXPORT_OUT_FILE, err})
+		}
+	}
+
+	errs = append(errs, exportSuspense(fsys, outDir, pages)...)
+
+	err = copyAssets(outDir)
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	fmt.Printf("Exported: %d pages to %s\n", len(pages), outDir)
+	if len(unexportable) > 0 {
+		fmt.Println("Unexportable:")
+		for _, path := range unexportable {
+			fmt.Println("   -", path)
+		}
+	}
+
+	return newRenderError(errs)
+}
+
+// exportSlugPage renders the page for a single set of @params inside the index.html in @fsys, as Render() only renders the slug pattern
+func (g *Temp) exportSlugPage(fsys fs.FS, pageProps PageProps, indexPath string, params map[string]string) (exportedPage, error) {
+
+	path, err := expandSlugs(pageProps.Path, params)
+	if err != nil {
+		return exportedPage{}, &RenderFailure{pageProps.Path, EXPORT_OUT_FILE, err}
+	}
+
+	r, _ := http.NewRequest("GET", path, nil)
+	for name, value := range params {
+		r.SetPathValue(name, value)
+	}
+	r = WithPathParams(r, params)
+	w := NewRecordingResponseWriter()
+
+	pageOut, err := g.invokeHandlerFunction(pageProps.ParamType, pageProps.Handler, w, r)
+	if err != nil {
+		return exportedPage{}, &RenderFailure{path, EXPORT_OUT_FILE, err}
+	}
+
+	ctx, suspense := utils.NewSuspenseRecorder(context.Background(), r)
+
+	full, err := renderFullPage(ctx, fsys, pageOut, pageProps, indexPath)
+	if err != nil {
+		return exportedPage{}, &RenderFailure{path, EXPORT_OUT_FILE, err}
+	}
+
+	_, body, err := renderPageBody(ctx, pageOut, pageProps)
+	if err != nil {
+		return exportedPage{}, &RenderFailure{path, EXPORT_BODY_OUT_FILE, err}
+	}
+
+	return exportedPage{path, g.config.minifyHTML(pageProps.Path, full), g.config.minifyHTML(pageProps.Path, body), suspense.Boundaries()}, nil
+}
+
+// exportSuspense writes the runtime suspense boundaries of the exported output to their URL, as there's no Run() to serve them
+func exportSuspense(fsys fs.FS, outDir string, pages []exportedPage) []error {
+	manifest, err := loadManifest(fsys)
+	if err != nil {
+		return []error{err}
+	}
+
+	boundaries := make(map[string]utils.SuspenseBoundary)
+	for _, entry := range manifest.entries() {
+		for _, boundary := range entry.Suspense {
+			boundaries[boundary.ID()] = boundary
+		}
+	}
+	for _, page := range pages {
+		for _, boundary := range page.Suspense {
+			boundaries[boundary.ID()] = boundary
+		}
+	}
+
+	ids := make([]string, 0, len(boundaries))
+	for id := range boundaries {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	var errs []error
+	for _, id := range ids {
+		boundary := boundaries[id]
+		fmt.Println("Suspense:", boundary.URL(), boundary.Name)
+
+		r, _ := http.NewRequest("GET", boundary.URL(), nil)
+
+		cmp, err := boundary.Component(r)
+		if err != nil {
+			errs = append(errs, &RenderFailure{utils.SUSPENSE_PATH, boundary.URL(), fmt.Errorf("suspense %s: %w", boundary.Name, err)})
+			continue
+		}
+
+		var buffer bytes.Buffer
+		err = cmp.Render(r.Context(), &buffer)
+		if err == nil {
+			err = writeFileTo(outDir, boundary.URL(), buffer.Bytes())
+		}
+		if err != nil {
+			errs = append(errs, &RenderFailure{utils.SUSPENSE_PATH, boundary.URL(), fmt.Errorf("suspense %s: %w", boundary.Name, err)})
+		}
+	}
+	return errs
+}
+
+// expandSlugs replaces every {slug} of @path with its value in @params
+func expandSlugs(path string, params map[string]string) (string, error) {
+	var missing []string
+
+	expanded := slugRegex.ReplaceAllStringFunc(path, func(slug string) string {
+		name := slug[1 : len(slug)-1]
+		value, ok := params[name]
+		if !ok {
+			missing = append(missing, name)
+		}
+		return value
+	})
+
+	if len(missing) > 0 {
+		return "", fmt.Errorf("%s missing value(s) for: %s", PARAMS, strings.Join(missing, ", "))
+	}
+	return expanded, nil
+}
+
+// rewriteBoostLinks adds the attributes equivalent to setBoostHeaders() to every <a> linking to an @exported page,
+// so htmx swaps in its index-body.html instead of relying on the server to detect a boosted request.
+// The target & swap are those of the index @matcher resolves the linked page to
+func rewriteBoostLinks(content []byte, exported utils.StringSet, matcher *routeMatcher) []byte {
+	return anchorTagRegex.ReplaceAllFunc(content, func(tag []byte) []byte {
+		if strings.Contains(string(tag), "hx-get=") {
+			return tag
+		}
+
+		href := hrefAttrRegex.FindSubmatch(tag)
+		if href == nil {
+			return tag
+		}
+
+		link := string(bytes.Join(href[1:], nil))
+		if link != "/" {
+			link = strings.TrimSuffix(link, "/")
+		}
+		if !exported.Contains(link) {
+			return tag
+		}
+
+		var index IndexProps
+		if _, indexPath, ok := matcher.match(link); ok {
+			index = Index[indexPath]
+		}
+		target, swap := index.boost()
+
+		attrs := fmt.Sprintf(
+			` hx-get="%s" hx-target="%s" hx-swap="%s" hx-push-url="%s"`,
+			path.Join(link, EXPORT_BODY_OUT_FILE),
+			target,
+			swap,
+			link,
+		)
+
+		// the / of a self-closing tag follows whitespace or a quoted value, otherwise it ends an unquoted value e.g. href=/docs/
+		end := len(tag) - 1
+		if tag[end-1] == '/' && bytes.ContainsAny(tag[end-2:end-1], " \t\n\r\f\"'") {
+			end--
+		}
+
+		rewritten := make([]byte, 0, len(tag)+len(attrs))
+		rewritten = append(rewritten, tag[:end]...)
+		rewritten = append(rewritten, attrs...)
+		rewritten = append(rewritten, tag[end:]...)
+		return rewritten
+	})
+}
+
+// copyAssets copies every file served from HTML_SERVE_PATH into @outDir, except the pre-rendered HTML_OUT_DIR
+func copyAssets(outDir string) error {
+	assetDir := filepath.Clean("." + HTML_SERVE_PATH)
+	htmlDir := filepath.Clean(HTML_OUT_DIR)
+	exportDir := filepath.Clean(outDir)
+
+	err := filepath.Walk(assetDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			if path == htmlDir || path == exportDir {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		src, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer src.Close()
+
+		dst, err := utils.CreateFile(filepath.Join(DIR, path), outDir)
+		if err != nil {
+			return err
+		}
+
+		_, err = io.Copy(dst, src)
+		if err != nil {
+			dst.Close()
+			return err
+		}
+		return dst.Close()
+	})
+
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,47 @@
+package temporary
+
+import (
+	"testing"
+
+	"calebsideras.com/temporary/temporary/utils"
+)
+
+func TestRewriteBoostLinks(t *testing.T) {
+	matcher, err := newRouteMatcher(map[string]string{"/": "/", "/docs": "/"}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exported := utils.NewStringSet()
+	exported.Add("/")
+	exported.Add("/docs")
+
+	attrs := func(link string, body string) string {
+		return ` hx-get="` + body + `" hx-target="` + BOOST_RETARGET + `" hx-swap="` + BOOST_RESWAP + `" hx-push-url="` + link + `"`
+	}
+	docs := attrs("/docs", "/docs/"+EXPORT_BODY_OUT_FILE)
+
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"quoted", `<a href="/docs">`, `<a href="/docs"` + docs + `>`},
+		{"self-closing quoted", `<a href="/docs"/>`, `<a href="/docs"` + docs + `/>`},
+		{"self-closing after space", `<a href=/docs />`, `<a href=/docs ` + docs + `/>`},
+		{"unquoted", `<a href=/docs>`, `<a href=/docs` + docs + `>`},
+		{"unquoted trailing slash", `<a href=/docs/>`, `<a href=/docs/` + docs + `>`},
+		{"unquoted root", `<a href=/>`, `<a href=/` + attrs("/", "/"+EXPORT_BODY_OUT_FILE) + `>`},
+		{"not exported", `<a href=/blog/>`, `<a href=/blog/>`},
+		{"already boosted", `<a href="/docs" hx-get="/x">`, `<a href="/docs" hx-get="/x">`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := string(rewriteBoostLinks([]byte(tt.content), exported, matcher))
+			if got != tt.want {
+				t.Fatalf("rewriteBoostLinks() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
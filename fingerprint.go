@@ -0,0 +1,81 @@
+package temporary
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+)
+
+// fingerprint hashes @parts into a single value. An empty part means the input is unknown, so is the fingerprint
+func fingerprint(parts ...string) string {
+	h := sha256.New()
+	for _, p := range parts {
+		if p == "" {
+			return ""
+		}
+		fmt.Fprintf(h, "%d:%s", len(p), p)
+	}
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
+// handlerSourceHash hashes every file in the package directory of @handler, returns "" if the directory can't be determined
+func (s *renderState) handlerSourceHash(handler interface{}) string {
+	fn := runtime.FuncForPC(reflect.ValueOf(handler).Pointer())
+	if fn == nil {
+		return ""
+	}
+
+	// e.g. calebsideras.com/temporary/src/app/docs/index.Page_
+	name := fn.Name()
+	slash := strings.LastIndex(name, "/")
+	dot := strings.Index(name[slash+1:], ".")
+	if dot == -1 {
+		return ""
+	}
+	pkgPath := name[:slash+1+dot]
+
+	if !strings.HasPrefix(pkgPath, PROJECT_PACKAGE) {
+		return ""
+	}
+	dir := strings.TrimPrefix(pkgPath, PROJECT_PACKAGE)
+
+	if hash, ok := s.sourceHashes.Load(dir); ok {
+		return hash.(string)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return ""
+	}
+
+	h := sha256.New()
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return ""
+		}
+		fmt.Fprintf(h, "%s:%d:", entry.Name(), len(content))
+		h.Write(content)
+	}
+
+	hash := fmt.Sprintf("%x", h.Sum(nil))
+	s.sourceHashes.Store(dir, hash)
+	return hash
+}
+
+// dependencySnapshot serializes the user dependency, so outputs are re-rendered whenever it changes
+func dependencySnapshot(dependency interface{}) string {
+	snapshot, err := json.Marshal(dependency)
+	if err != nil {
+		return fmt.Sprintf("%#v", dependency)
+	}
+	return string(snapshot)
+}
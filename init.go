@@ -64,6 +64,7 @@ import "%s"
 type Temp struct {
 	dependency %s
 	dependencyName string
+	config Config
 }
 `, varType)
 
@@ -104,7 +105,6 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
-	"html/template"
 	"net/http"
 	"path/filepath"
 
@@ -119,7 +119,7 @@ func getPartialPageBoostFnStr(depType string) string {
 func getPartialPageBoostFn(partialPageFn func(w http.ResponseWriter, r *http.Request, dep %s, buffer *bytes.Buffer)) func(w http.ResponseWriter, r *http.Request, dep %s, buffer *bytes.Buffer) {
 	return func(w http.ResponseWriter, r *http.Request, dep %s, buffer *bytes.Buffer) {
 		partialPageFn(w, r, dep, buffer)
-		setBoostHeaders(w)
+		setBoostHeaders(w, r)
 	}
 }
 	`, depType, depType, depType)
@@ -140,7 +140,7 @@ func getDynamicPageClosure(page PageProps, index IndexProps) (func(w http.Respon
 			buffer.Write(mData.Bytes())
 			err := pageFn(w, r, dep).Render(r.Context(), buffer)
 			if err != nil {
-				//set some error stuff
+				panic(fmt.Errorf("Error rendering page.go output from path: %%s\n%%v", page.Path, err))
 			}
 		},
 		nil
@@ -171,18 +171,18 @@ func getDynamicPageClosure(page PageProps, index IndexProps) (func(w http.Respon
 
 func getStaticPageClosureStr(depType string) string {
 	return `
-func getStaticPageClosure(page PageProps) (func(http.ResponseWriter, *http.Request, ` + depType + `, *bytes.Buffer), error) {
+func getStaticPageClosure(page PageProps, cache *staticCache) (func(http.ResponseWriter, *http.Request, ` + depType + `, *bytes.Buffer), error) {
 
-	pageDir := filepath.Clean(filepath.Join(HTML_OUT_DIR, page.Path, PAGE_BODY_OUT_FILE_W_METADATA))
+	pageFile := filepath.Join(page.Path, PAGE_BODY_OUT_FILE_W_METADATA)
 
 	return func(w http.ResponseWriter, r *http.Request, def ` + depType + `, buffer *bytes.Buffer) {
 
-			pageTpl, err := template.ParseFiles(pageDir)
+			output, err := cache.get(pageFile)
 			if err != nil {
-				panic(fmt.Errorf("Error parsing pre-rendered %s from path: %s\n%v", PAGE_BODY_OUT_FILE_W_METADATA, pageDir, err))
+				panic(fmt.Errorf("Error loading pre-rendered %s of path: %s\n%v", PAGE_BODY_OUT_FILE_W_METADATA, page.Path, err))
 			}
 
-			pageTpl.Execute(buffer, nil)
+			buffer.Write(output.Content)
 
 		},
 		nil
@@ -202,7 +202,7 @@ func getDynamicRouteClosure(route RouteProps) (func(http.ResponseWriter, *http.R
 	return func(w http.ResponseWriter, r *http.Request, dep ` + depType + `, buffer *bytes.Buffer) {
 			err := routeFn(w, r, dep).Render(r.Context(), buffer)
 			if err != nil {
-				//set some error stuff
+				panic(fmt.Errorf("Error rendering route.go output from path: %s\n%v", route.Path, err))
 			}
 		},
 		nil
@@ -213,18 +213,18 @@ func getDynamicRouteClosure(route RouteProps) (func(http.ResponseWriter, *http.R
 
 func getStaticRouteClosureStr(depType string) string {
 	return `			
-func getStaticRouteClosure(route RouteProps) (func(http.ResponseWriter, *http.Request,` + depType + `, *bytes.Buffer), error) {
+func getStaticRouteClosure(route RouteProps, cache *staticCache) (func(http.ResponseWriter, *http.Request,` + depType + `, *bytes.Buffer), error) {
 
-	pageDir := filepath.Clean(filepath.Join(HTML_OUT_DIR, route.Path, ROUTE_OUT_FILE))
+	routeFile := filepath.Join(route.Path, ROUTE_OUT_FILE)
 
 	return func(w http.ResponseWriter, r *http.Request, dep ` + depType + `, buffer *bytes.Buffer) {
 
-			pageTpl, err := template.ParseFiles(pageDir)
+			output, err := cache.get(routeFile)
 			if err != nil {
-				panic(fmt.Errorf("Error parsing pre-rendered %s from path: %s\n%v", ROUTE_OUT_FILE, pageDir, err))
+				panic(fmt.Errorf("Error loading pre-rendered %s of path: %s\n%v", ROUTE_OUT_FILE, route.Path, err))
 			}
 
-			pageTpl.Execute(buffer, nil)
+			buffer.Write(output.Content)
 
 		},
 		nil
@@ -234,10 +234,10 @@ func getStaticRouteClosure(route RouteProps) (func(http.ResponseWriter, *http.Re
 
 func getStaticFullPageClosureStr(depType string) string {
 	return `
-func getStaticFullPageClosure(page PageProps, index IndexProps, indexPath string) (func(http.ResponseWriter, *http.Request, ` + depType + `, *bytes.Buffer), error) {
+func getStaticFullPageClosure(page PageProps, index IndexProps, indexPath string, cache *staticCache) (func(http.ResponseWriter, *http.Request, ` + depType + `, *bytes.Buffer), error) {
 
-	fullPageDir := filepath.Clean(filepath.Join(HTML_OUT_DIR, page.Path, PAGE_OUT_FILE))
-	pageDir := filepath.Clean(filepath.Join(HTML_OUT_DIR, page.Path, PAGE_BODY_OUT_FILE))
+	fullPageFile := filepath.Join(page.Path, PAGE_OUT_FILE)
+	pageFile := filepath.Join(page.Path, PAGE_BODY_OUT_FILE)
 
 	switch index.HandleType {
 	case IndexHandle:
@@ -250,14 +250,14 @@ func getStaticFullPageClosure(page PageProps, index IndexProps, indexPath string
 
 		return func(w http.ResponseWriter, r *http.Request, dep ` + depType + `, buffer *bytes.Buffer) {
 
-			pageTpl, err := template.ParseFiles(pageDir)
+			output, err := cache.get(pageFile)
 			if err != nil {
-				panic(fmt.Errorf("Error parsing pre-rendered %s from path: %s\n%v", PAGE_BODY_OUT_FILE, pageDir, err))
+				panic(fmt.Errorf("Error loading pre-rendered %s of path: %s\n%v", PAGE_BODY_OUT_FILE, page.Path, err))
 			}
 
-			err = indexFn(w, r, dep).Render(templ.WithChildren(r.Context(), templ.FromGoHTML(pageTpl, nil)), buffer)
+			err = indexFn(w, r, dep).Render(templ.WithChildren(r.Context(), output.component()), buffer)
 			if err != nil {
-				//set some error stuff
+				panic(fmt.Errorf("Error rendering index.go output from path: %s\n%v", index.Path, err))
 			}
 
 			addMetadataIntoBuffer(buffer, meta)
@@ -266,12 +266,12 @@ func getStaticFullPageClosure(page PageProps, index IndexProps, indexPath string
 
 	case IndexRender:
 		return func(w http.ResponseWriter, r *http.Request, dep ` + depType + `, buffer *bytes.Buffer) {
-			fullPageTpl, err := template.ParseFiles(fullPageDir)
+			output, err := cache.get(fullPageFile)
 			if err != nil {
-				panic(fmt.Errorf("Error parsing pre-rendered %s from path: %s\n%v", PAGE_OUT_FILE, pageDir, err))
+				panic(fmt.Errorf("Error loading pre-rendered %s of path: %s\n%v", PAGE_OUT_FILE, page.Path, err))
 			}
 
-			fullPageTpl.Execute(buffer, nil)
+			buffer.Write(output.Content)
 		}, nil
 	}
 
@@ -282,7 +282,7 @@ func getStaticFullPageClosure(page PageProps, index IndexProps, indexPath string
 
 func getDynamicFullPageClosureStr(depType string) string {
 	return `
-func getDynamicFullPageClosure(page PageProps, index IndexProps, indexPath string) (func(http.ResponseWriter, *http.Request,` + depType + `, *bytes.Buffer), error) {
+func getDynamicFullPageClosure(page PageProps, index IndexProps, indexPath string, cache *staticCache) (func(http.ResponseWriter, *http.Request,` + depType + `, *bytes.Buffer), error) {
 
 	pageFn := userFunctionWrapper(page.Handler, page.ParamType)
 	if pageFn == nil {
@@ -301,7 +301,7 @@ func getDynamicFullPageClosure(page PageProps, index IndexProps, indexPath strin
 		return func(w http.ResponseWriter, r *http.Request, dep ` + depType + `, buffer *bytes.Buffer) {
 			err := indexFn(w, r, dep).Render(templ.WithChildren(r.Context(), pageFn(w, r, dep)), buffer)
 			if err != nil {
-				//set some error stuff
+				panic(fmt.Errorf("Error rendering index.go output from path: %s\n%v", index.Path, err))
 			}
 
 			addMetadataIntoBuffer(buffer, meta)
@@ -310,31 +310,20 @@ func getDynamicFullPageClosure(page PageProps, index IndexProps, indexPath strin
 
 	case IndexRender:
 
-		dir := filepath.Clean(filepath.Join(HTML_OUT_DIR, indexPath, INDEX_OUT_FILE))
-
 		return func(w http.ResponseWriter, r *http.Request, dep ` + depType + `, buffer *bytes.Buffer) {
 
-			indexTpl, err := template.ParseFiles(dir)
-			if err != nil {
-				panic(fmt.Errorf("Error parsing index.html from path: %s\n%v", dir, err))
-			}
-
-			pageTpl, err := templ.ToGoHTML(r.Context(), pageFn(w, r, dep))
-
-			if err != nil {
-				panic(fmt.Errorf("Error converting page.go output from path: %s to template.HTML\n%v", page.Path, err))
-			}
-
-			_, err = indexTpl.New("page").Parse(string(pageTpl))
-
+			layout, err := cache.layout(indexPath)
 			if err != nil {
-				panic(fmt.Errorf("Error converting page.go output from path: %s to template.HTML\n%v", page.Path, err))
+				panic(fmt.Errorf("Error loading pre-rendered %s of path: %s\n%v", INDEX_OUT_FILE, indexPath, err))
 			}
 
-			err = indexTpl.Execute(buffer, nil)
+			// page output is inserted as it is, never parsed as a template
+			err = layout.write(buffer, func(buffer *bytes.Buffer) error {
+				return pageFn(w, r, dep).Render(r.Context(), buffer)
+			})
 
 			if err != nil {
-				panic(fmt.Errorf("Error executing template \n%v", err))
+				panic(fmt.Errorf("Error rendering page.go output from path: %s\n%v", page.Path, err))
 			}
 
 			addMetadataIntoBuffer(buffer, meta)
@@ -391,7 +380,7 @@ func executeAppropriateFn(w http.ResponseWriter, r *http.Request, dep %s, buffer
 		boostPage(w, r, dep, buffer)
 	case HxGet_Index:
 		index(w, r, dep, buffer)
-	case HxBoost_Index, NormalRequest:
+	case HxBoost_Index, NormalRequest, HxHistory_Restore, HxBoost_Layout:
 		boostIndex(w, r, dep, buffer)
 	}
 }
@@ -0,0 +1,147 @@
+package temporary
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"calebsideras.com/temporary/temporary/utils"
+)
+
+// PAGE_MARKER is written by utils.PageTemplate() in place of the page, i.e. the { children... } of a pre-rendered index.html.
+// Content rendered by templ can't produce it, as templ escapes its quotes
+const PAGE_MARKER = `{{ block "page" . }}{{end}}`
+
+// indexLayout is a pre-rendered index.html split at PAGE_MARKER.
+// Pages are inserted between head & tail as opaque bytes, neither is ever parsed as a Go template
+type indexLayout struct {
+	head []byte
+	tail []byte
+}
+
+func splitIndexLayout(content []byte) (indexLayout, error) {
+	if n := bytes.Count(content, []byte(PAGE_MARKER)); n != 1 {
+		return indexLayout{}, fmt.Errorf("expected the index to render { children... } once, found %d", n)
+	}
+
+	head, tail, _ := bytes.Cut(content, []byte(PAGE_MARKER))
+	return indexLayout{head, tail}, nil
+}
+
+// write writes the layout with @page in place of PAGE_MARKER
+func (l indexLayout) write(buffer *bytes.Buffer, page func(*bytes.Buffer) error) error {
+	buffer.Write(l.head)
+	err := page(buffer)
+	if err != nil {
+		return err
+	}
+	buffer.Write(l.tail)
+	return nil
+}
+
+// parentIndex returns the deepest other index whose path is an ancestor of @indexPath
+func parentIndex(indexPath string) (string, bool) {
+	parent, found := "", false
+	for path := range Index {
+		if path == indexPath || !isAncestorPath(path, indexPath) {
+			continue
+		}
+		if !found || len(path) > len(parent) {
+			parent, found = path, true
+		}
+	}
+	return parent, found
+}
+
+func isAncestorPath(ancestor string, path string) bool {
+	return ancestor == "/" || strings.HasPrefix(path, strings.TrimSuffix(ancestor, "/")+"/")
+}
+
+// layoutChain returns the indexes a page of @indexPath is rendered in, outermost first.
+// A Nested index is rendered inside its parent's { children... }, any other index is a document of its own
+func layoutChain(indexPath string) []string {
+	chain := []string{indexPath}
+	for {
+		index, ok := Index[indexPath]
+		if !ok || !index.Nested || index.HandleType != IndexRender {
+			return chain
+		}
+
+		parent, ok := parentIndex(indexPath)
+		if !ok || Index[parent].HandleType != IndexRender {
+			return chain
+		}
+
+		chain = append([]string{parent}, chain...)
+		indexPath = parent
+	}
+}
+
+// sharedLayout returns the deepest layout the pages of the @current & @target indexes are both rendered in
+func sharedLayout(current string, target string) (string, bool) {
+	currentChain, targetChain := layoutChain(current), layoutChain(target)
+
+	shared, found := "", false
+	for i := 0; i < len(currentChain) && i < len(targetChain); i++ {
+		if currentChain[i] != targetChain[i] {
+			break
+		}
+		shared, found = currentChain[i], true
+	}
+	return shared, found
+}
+
+// writeLayoutSwap answers HxBoost_Layout with the page @body inside the indexes nested below the layout it shares with HX-Current-URL,
+// swapped into the shared layout's Boost target so everything outside of it e.g. sidebars & players survives the navigation
+func (g Temp) writeLayoutSwap(w http.ResponseWriter, r *http.Request, page PageProps, index IndexProps, cache *staticCache, body []byte) {
+	current, _ := utils.LastElementOfURL(utils.GetHtmxRequestURL(r))
+	_, currentIndex, _ := matchIndex(current)
+	shared, _ := sharedLayout(currentIndex, index.Path)
+
+	chain := layoutChain(index.Path)
+	for len(chain) > 0 && chain[0] != shared {
+		chain = chain[1:]
+	}
+
+	var layouts []indexLayout
+	var metadata []string
+	for _, path := range chain[1:] {
+		output, err := cache.get(filepath.Join(path, INDEX_BODY_OUT_FILE))
+		if err != nil {
+			g.handleRenderError(err, w, r)
+			return
+		}
+
+		layout, err := splitIndexLayout(output.Content)
+		if err != nil {
+			g.handleRenderError(fmt.Errorf("%s of path: %s: %w", INDEX_BODY_OUT_FILE, path, err), w, r)
+			return
+		}
+
+		layouts = append(layouts, layout)
+		metadata = append(metadata, Index[path].Metadata...)
+	}
+
+	var buffer bytes.Buffer
+
+	pageMeta := initPageMetadataVar(append(metadata, page.Metadata...))
+	buffer.Write(pageMeta.Bytes())
+
+	for _, layout := range layouts {
+		buffer.Write(layout.head)
+	}
+	buffer.Write(body)
+	for i := len(layouts) - 1; i >= 0; i-- {
+		buffer.Write(layouts[i].tail)
+	}
+
+	target, swap := Index[shared].boost()
+	w.Header().Set("HX-Retarget", target)
+	w.Header().Set("HX-Reswap", swap)
+
+	content := g.config.minifyHTML(page.Path, buffer.Bytes())
+	g.writeRequest(w, r, utils.GenerateETag(string(content)), time.Time{}, content, nil)
+}
@@ -0,0 +1,97 @@
+package temporary
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/a-h/templ"
+)
+
+// page bodies that would break, or be executed by, a layout parsed as a Go template
+var adversarialPages = []string{
+	`{{`,
+	`}}`,
+	PAGE_MARKER,
+	`{{template "page" .}}`,
+	`{{ define "page" }}hijacked{{ end }}`,
+	`<p>{{ .Secret }}</p></body></html>`,
+	`</body>`,
+	`<script>if (a {{ b) {}</script>`,
+}
+
+func rawComponent(content string) templ.Component {
+	return templ.ComponentFunc(func(ctx context.Context, w io.Writer) error {
+		_, err := io.WriteString(w, content)
+		return err
+	})
+}
+
+func TestSplitIndexLayoutMarkerCount(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr bool
+	}{
+		{"none", `<html><body></body></html>`, true},
+		{"one", `<html><body>` + PAGE_MARKER + `</body></html>`, false},
+		{"two", `<html><body>` + PAGE_MARKER + PAGE_MARKER + `</body></html>`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := splitIndexLayout([]byte(tt.content))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("splitIndexLayout() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRenderFullPageAdversarialContent(t *testing.T) {
+	head := `<html><head><script>var t = "{{ .Title }}";</script></head><body><main>`
+	tail := `</main></body></html>`
+	fsys := fstest.MapFS{
+		INDEX_OUT_FILE: {Data: []byte(head + PAGE_MARKER + tail)},
+	}
+
+	for _, page := range adversarialPages {
+		t.Run(page, func(t *testing.T) {
+			out, err := renderFullPage(context.Background(), fsys, rawComponent(page), PageProps{Path: "/a"}, "/")
+			if err != nil {
+				t.Fatalf("renderFullPage() error = %v", err)
+			}
+
+			want := head + page + tail
+			if string(out) != want {
+				t.Fatalf("renderFullPage() =\n%s\nwant\n%s", out, want)
+			}
+		})
+	}
+}
+
+func TestIndexLayoutWriteVerbatim(t *testing.T) {
+	layout, err := splitIndexLayout([]byte(`<body>{{ .Head }}` + PAGE_MARKER + `{{ .Tail }}</body>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	page := []byte(strings.Join(adversarialPages, "\n"))
+
+	var buffer bytes.Buffer
+	err = layout.write(&buffer, func(buffer *bytes.Buffer) error {
+		_, err := buffer.Write(page)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `<body>{{ .Head }}` + string(page) + `{{ .Tail }}</body>`
+	if buffer.String() != want {
+		t.Fatalf("write() =\n%s\nwant\n%s", buffer.String(), want)
+	}
+}
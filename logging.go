@@ -0,0 +1,144 @@
+package temporary
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+const REQUEST_ID_HEADER = "X-Request-ID"
+
+func (t requestType) String() string {
+	switch t {
+	case NormalRequest:
+		return "normal"
+	case HxGet_Index:
+		return "hx-get-index"
+	case HxGet_Page:
+		return "hx-get-page"
+	case HxBoost_Page:
+		return "hx-boost-page"
+	case HxBoost_Index:
+		return "hx-boost-index"
+	case ErrorRequest:
+		return "error"
+	case HxHistory_Restore:
+		return "hx-history-restore"
+	case HxBoost_Layout:
+		return "hx-boost-layout"
+	}
+	return "unknown"
+}
+
+// accessLog is filled in by the handlers of a single request & logged once it completes
+type accessLog struct {
+	requestID string
+	cacheHit  bool
+}
+
+type accessLogKey struct{}
+
+func getAccessLog(r *http.Request) *accessLog {
+	entry, _ := r.Context().Value(accessLogKey{}).(*accessLog)
+	if entry == nil {
+		return &accessLog{}
+	}
+	return entry
+}
+
+// RequestID returns the ID of @r, taken from its X-Request-ID header or generated, as logged in its access log
+func RequestID(r *http.Request) string {
+	return getAccessLog(r).requestID
+}
+
+// markCacheHit records that @r was served from the pre-rendered static cache
+func markCacheHit(r *http.Request) {
+	getAccessLog(r).cacheHit = true
+}
+
+func newRequestID() string {
+	id := make([]byte, 8)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
+
+// accessLogWriter records the status & number of bytes written by a handler
+type accessLogWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (w *accessLogWriter) WriteHeader(statusCode int) {
+	if w.status == 0 {
+		w.status = statusCode
+	}
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *accessLogWriter) Write(bytes []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(bytes)
+	w.bytes += n
+	return n, err
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (w *accessLogWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// logRequests wraps the @handler of the user defined @path with an access log written to Config.Logger.
+// @kind is static or dynamic & @handlerType page or route
+func (g Temp) logRequests(path string, kind string, handlerType string, handler http.HandlerFunc) http.HandlerFunc {
+	logger := g.config.logger()
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		entry := &accessLog{requestID: r.Header.Get(REQUEST_ID_HEADER)}
+		if entry.requestID == "" {
+			entry.requestID = newRequestID()
+		}
+		w.Header().Set(REQUEST_ID_HEADER, entry.requestID)
+
+		r = r.WithContext(context.WithValue(r.Context(), accessLogKey{}, entry))
+		lw := &accessLogWriter{ResponseWriter: w}
+
+		// deferred to also log aborted requests
+		defer func() {
+			status := lw.status
+			if status == 0 {
+				status = http.StatusOK
+			}
+
+			level := slog.LevelInfo
+			if status >= http.StatusInternalServerError {
+				level = slog.LevelError
+			}
+
+			logger.LogAttrs(r.Context(), level, "request",
+				slog.String("request_id", entry.requestID),
+				slog.String("method", r.Method),
+				slog.String("path", r.URL.Path),
+				slog.String("remote_addr", r.RemoteAddr),
+				slog.Int("status", status),
+				slog.Int("bytes", lw.bytes),
+				slog.Duration("latency", time.Since(start)),
+				slog.String("request_type", determineRequest(r).String()),
+				slog.String("route", path),
+				slog.String("kind", kind),
+				slog.String("handler", handlerType),
+				slog.Bool("cache_hit", entry.cacheHit),
+				slog.Bool("not_modified", status == http.StatusNotModified),
+			)
+		}()
+
+		handler(lw, r)
+	}
+}
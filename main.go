@@ -12,6 +12,8 @@ const (
 	TS_EXT   = ".ts"
 	HTML_EXT = ".html"
 	TXT_EXT  = ".txt"
+	JSON_EXT = ".json"
+	GZ_EXT   = ".gz"
 
 	EXPORTED_HANDLE       = "Handle"
 	EXPORTED_RENDER       = "Render"
@@ -23,10 +25,14 @@ const (
 	PAGE     = "page"
 	INDEX    = "index"
 	ROUTE    = "route"
-	ETAG     = "etag_file"
+	MANIFEST = "manifest"
 	BODY     = "-body"
 	MET_TAG  = "-metadata"
 	METADATA = "Metadata"
+	PARAMS   = "StaticParams"
+	BOOST    = "Boost"
+	NESTED   = "Nested"
+	CACHE    = "Cache"
 
 	PAGE_BODY                     = PAGE + BODY
 	PAGE_FILE                     = PAGE + GO_EXT
@@ -35,11 +41,14 @@ const (
 	INDEX_FILE                    = INDEX + GO_EXT
 	ROUTE_FILE                    = ROUTE + GO_EXT
 	INDEX_OUT_FILE                = INDEX + HTML_EXT
+	INDEX_BODY_OUT_FILE           = INDEX + BODY + HTML_EXT
 	PAGE_OUT_FILE                 = PAGE + HTML_EXT
 	PAGE_BODY_OUT_FILE            = PAGE_BODY + HTML_EXT
 	PAGE_BODY_OUT_FILE_W_METADATA = PAGE_BODY + MET_TAG + HTML_EXT
 	ROUTE_OUT_FILE                = ROUTE + HTML_EXT
-	ETAG_FILE                     = ETAG + TXT_EXT
+	EXPORT_OUT_FILE               = INDEX + HTML_EXT
+	EXPORT_BODY_OUT_FILE          = INDEX + BODY + HTML_EXT
+	MANIFEST_FILE                 = MANIFEST + JSON_EXT
 
 	HTML_OUT_DIR = "./static/html/"
 )
@@ -0,0 +1,177 @@
+package temporary
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"calebsideras.com/temporary/temporary/utils"
+)
+
+const (
+	// MANIFEST_VERSION is bumped whenever the manifest format changes, older manifests are re-rendered
+	MANIFEST_VERSION = 2
+
+	HTML_CONTENT_TYPE = "text/html; charset=utf-8"
+)
+
+// RenderManifest is written by Render() & loaded by Run(), it describes every pre-rendered file
+type RenderManifest struct {
+	Version int             `json:"version"`
+	Entries []ManifestEntry `json:"entries"`
+}
+
+type ManifestEntry struct {
+	Path        string            `json:"path"` // route path defined by the user
+	File        string            `json:"file"` // output file, relative to HTML_OUT_DIR
+	ETag        string            `json:"etag"`
+	Size        int               `json:"size"`
+	RenderedAt  time.Time         `json:"rendered_at"`
+	ContentType string            `json:"content_type"`
+	Encodings   []string          `json:"encodings,omitempty"` // pre-compressed siblings e.g. gzip -> File + GZ_EXT
+	Fingerprint string            `json:"fingerprint"`
+	Response    *RecordedResponse `json:"response,omitempty"` // headers & status set by the handler, replayed by Run()
+	// runtime suspense boundaries in the output, served by Run() at their stable URL
+	Suspense []utils.SuspenseBoundary `json:"suspense,omitempty"`
+	// Cache-Control policy of the page or route the output is served by, see Config.cacheControl()
+	CacheControl string `json:"cache_control,omitempty"`
+}
+
+// encodingFiles returns the output file & its pre-compressed siblings
+func (e ManifestEntry) encodingFiles() []string {
+	files := []string{e.File}
+	for _, encoding := range e.Encodings {
+		if encoding == GZIP_ENCODING {
+			files = append(files, e.File+GZ_EXT)
+		}
+	}
+	return files
+}
+
+func (e ManifestEntry) hasEncoding(encoding string) bool {
+	for _, enc := range e.Encodings {
+		if enc == encoding {
+			return true
+		}
+	}
+	return false
+}
+
+// outputFS is the pre-rendered output written by Render() to HTML_OUT_DIR
+func outputFS() fs.FS {
+	return os.DirFS(HTML_OUT_DIR)
+}
+
+// outputPath converts an output @file e.g. /docs/page.html to its path in an fs.FS, docs/page.html
+func outputPath(file string) string {
+	return strings.TrimPrefix(path.Clean(filepath.ToSlash(file)), "/")
+}
+
+// loadManifest reads the manifest of the last Render() from @fsys, returns nil if there is none
+func loadManifest(fsys fs.FS) (*RenderManifest, error) {
+	content, err := fs.ReadFile(fsys, MANIFEST_FILE)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var manifest RenderManifest
+	err = json.Unmarshal(content, &manifest)
+	if err != nil {
+		return nil, fmt.Errorf("Error parsing %s\n%w", MANIFEST_FILE, err)
+	}
+	return &manifest, nil
+}
+
+func writeManifest(entries map[string]ManifestEntry) error {
+	manifest := RenderManifest{Version: MANIFEST_VERSION}
+	for _, entry := range entries {
+		manifest.Entries = append(manifest.Entries, entry)
+	}
+	sort.Slice(manifest.Entries, func(i, j int) bool {
+		return manifest.Entries[i].File < manifest.Entries[j].File
+	})
+
+	content, err := json.MarshalIndent(manifest, "", "\t")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(MANIFEST_FILE, content)
+}
+
+// entries returns the manifest entries keyed by output file
+func (m *RenderManifest) entries() map[string]ManifestEntry {
+	entries := make(map[string]ManifestEntry)
+	if m == nil {
+		return entries
+	}
+	for _, entry := range m.Entries {
+		entries[entry.File] = entry
+	}
+	return entries
+}
+
+// verify checks the manifest can be served by Run(), i.e. its version is supported & every file it references exists in @fsys
+// with the size it was rendered with, e.g. not hand-edited or partially copied
+func (m *RenderManifest) verify(fsys fs.FS) error {
+	if m == nil {
+		return fmt.Errorf("missing %s, run Render() first", MANIFEST_FILE)
+	}
+
+	if m.Version != MANIFEST_VERSION {
+		return fmt.Errorf("%s version %d is not supported (expected %d), run Render() again", MANIFEST_FILE, m.Version, MANIFEST_VERSION)
+	}
+
+	var errs []error
+	for _, entry := range m.Entries {
+		for _, file := range entry.encodingFiles() {
+			info, err := fs.Stat(fsys, outputPath(file))
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s referenced by %s: %w", file, MANIFEST_FILE, err))
+				continue
+			}
+
+			// pre-compressed siblings have no size of their own in the manifest
+			if file == entry.File && info.Size() != int64(entry.Size) {
+				errs = append(errs, fmt.Errorf("%s is %d bytes, %s expects %d, run Render() again", file, info.Size(), MANIFEST_FILE, entry.Size))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// removeStaleOutputs deletes the files of the previous render that are no longer produced, returns the deleted files
+func removeStaleOutputs(previous map[string]ManifestEntry, current map[string]ManifestEntry) ([]string, error) {
+	var deleted []string
+	var errs []error
+
+	for file, entry := range previous {
+		if _, ok := current[file]; ok {
+			continue
+		}
+
+		for _, f := range entry.encodingFiles() {
+			err := os.Remove(filepath.Join(HTML_OUT_DIR, f))
+			if err != nil && !errors.Is(err, fs.ErrNotExist) {
+				errs = append(errs, err)
+				continue
+			}
+			deleted = append(deleted, f)
+		}
+
+		// only succeeds if the directory is now empty
+		os.Remove(filepath.Join(HTML_OUT_DIR, filepath.Dir(file)))
+	}
+
+	sort.Strings(deleted)
+	return deleted, errors.Join(errs...)
+}
@@ -0,0 +1,32 @@
+package temporary
+
+import (
+	"testing"
+	"testing/fstest"
+)
+
+func TestManifestVerify(t *testing.T) {
+	manifest := &RenderManifest{
+		Version: MANIFEST_VERSION,
+		Entries: []ManifestEntry{{Path: "/a", File: "/a/page.html", Size: 5}},
+	}
+
+	tests := []struct {
+		name    string
+		fsys    fstest.MapFS
+		wantErr bool
+	}{
+		{"matching", fstest.MapFS{"a/page.html": {Data: []byte("<p/>\n")}}, false},
+		{"missing", fstest.MapFS{}, true},
+		{"size mismatch", fstest.MapFS{"a/page.html": {Data: []byte("<p>edited</p>")}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := manifest.verify(tt.fsys)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("verify() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package temporary
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"sync/atomic"
+)
+
+// routeMatcher resolves a concrete path, e.g. the HX-Current-URL of a boosted request, to the route pattern serving it & its index.
+// Patterns without slugs are matched exactly, the others in order of specificity
+type routeMatcher struct {
+	basePath string
+	exact    map[string]string
+	patterns []routePattern
+}
+
+type routePattern struct {
+	pattern string
+	index   string
+	regex   *regexp.Regexp
+	literal int // length of the pattern outside its slugs, longer is more specific
+	regexes int // slugs constrained by a regex, more specific than plain slugs
+	slugs   int
+}
+
+// routes is compiled from PathToIndex by Handler(), before any request is served
+var routes atomic.Pointer[routeMatcher]
+
+// newRouteMatcher compiles @pathToIndex, whose keys are patterns e.g. /examples/{slug} or /posts/{id:[0-9]+}.
+// @basePath is the prefix the handler is mounted under, stripped from matched paths
+func newRouteMatcher(pathToIndex map[string]string, basePath string) (*routeMatcher, error) {
+	m := &routeMatcher{basePath: cleanRoutePath(basePath), exact: make(map[string]string)}
+	if m.basePath == "/" {
+		m.basePath = ""
+	}
+
+	for pattern, index := range pathToIndex {
+		if !slugRegex.MatchString(pattern) {
+			m.exact[cleanRoutePath(pattern)] = index
+			continue
+		}
+
+		regex, err := compileRoutePattern(cleanRoutePath(pattern))
+		if err != nil {
+			return nil, fmt.Errorf("route %s: %w", pattern, err)
+		}
+
+		slugs := slugRegex.FindAllString(pattern, -1)
+		regexes := 0
+		for _, slug := range slugs {
+			if strings.Contains(slug, ":") {
+				regexes++
+			}
+		}
+
+		m.patterns = append(m.patterns, routePattern{
+			pattern: pattern,
+			index:   index,
+			regex:   regex,
+			literal: len(pattern) - len(strings.Join(slugs, "")),
+			regexes: regexes,
+			slugs:   len(slugs),
+		})
+	}
+
+	sort.Slice(m.patterns, func(i, j int) bool {
+		a, b := m.patterns[i], m.patterns[j]
+		if a.literal != b.literal {
+			return a.literal > b.literal
+		}
+		if a.regexes != b.regexes {
+			return a.regexes > b.regexes
+		}
+		if a.slugs != b.slugs {
+			return a.slugs < b.slugs
+		}
+		return a.pattern < b.pattern
+	})
+
+	return m, nil
+}
+
+// compileRoutePattern matches a {slug} with a single path segment, a {slug:regex} with its regex
+func compileRoutePattern(pattern string) (*regexp.Regexp, error) {
+	var expr strings.Builder
+	expr.WriteString("^")
+
+	last := 0
+	for _, loc := range slugRegex.FindAllStringSubmatchIndex(pattern, -1) {
+		expr.WriteString(regexp.QuoteMeta(pattern[last:loc[0]]))
+
+		_, slugExpr, ok := strings.Cut(pattern[loc[2]:loc[3]], ":")
+		if !ok {
+			slugExpr = "[^/]+"
+		}
+		expr.WriteString("(?:" + slugExpr + ")")
+
+		last = loc[1]
+	}
+	expr.WriteString(regexp.QuoteMeta(pattern[last:]))
+	expr.WriteString("$")
+
+	return regexp.Compile(expr.String())
+}
+
+// cleanRoutePath drops trailing slashes & dot segments, the root is "/"
+func cleanRoutePath(p string) string {
+	return path.Clean("/" + p)
+}
+
+// match returns the pattern & index of the concrete @p
+func (m *routeMatcher) match(p string) (string, string, bool) {
+	p = cleanRoutePath(p)
+	if m.basePath != "" {
+		if p == m.basePath {
+			p = "/"
+		} else if strings.HasPrefix(p, m.basePath+"/") {
+			p = p[len(m.basePath):]
+		}
+	}
+
+	if index, ok := m.exact[p]; ok {
+		return p, index, true
+	}
+
+	for _, pattern := range m.patterns {
+		if pattern.regex.MatchString(p) {
+			return pattern.pattern, pattern.index, true
+		}
+	}
+	return "", "", false
+}
+
+// matchIndex returns the pattern & index serving the concrete @p, looking it up in PathToIndex as is until Handler() compiles the routes
+func matchIndex(p string) (string, string, bool) {
+	if m := routes.Load(); m != nil {
+		return m.match(p)
+	}
+	index, ok := PathToIndex[p]
+	return p, index, ok
+}
@@ -0,0 +1,33 @@
+// Package muxrouter adapts a gorilla/mux router to temporary.Router, so only the apps using gorilla/mux depend on it
+package muxrouter
+
+import (
+	"net/http"
+
+	"calebsideras.com/temporary/temporary"
+	"github.com/gorilla/mux"
+)
+
+type muxRouter struct {
+	*mux.Router
+}
+
+// New adapts @r, slugs are available through temporary.PathParams & still through mux.Vars
+func New(r *mux.Router) temporary.Router {
+	return muxRouter{r}
+}
+
+func (m muxRouter) HandlePath(path string, handler http.HandlerFunc) error {
+	route := m.HandleFunc(path+"{slash:/?}", func(w http.ResponseWriter, r *http.Request) {
+		params := make(map[string]string)
+		for name, value := range mux.Vars(r) {
+			if name != "slash" {
+				params[name] = value
+			}
+		}
+		handler(w, temporary.WithPathParams(r, params))
+	})
+
+	// e.g. an invalid slug regex
+	return route.GetError()
+}
@@ -0,0 +1,139 @@
+package temporary
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"runtime/debug"
+	"strconv"
+
+	"calebsideras.com/temporary/temporary/utils"
+	"github.com/a-h/templ"
+)
+
+// ERROR_HEADER marks the error responses written by Run(), its value is the status
+const ERROR_HEADER = "X-Temporary-Error"
+
+// HTMX_ERROR_SCRIPT makes htmx swap the error responses written by Run(), as it doesn't swap 4xx & 5xx responses by default.
+// Include it in the index e.g. through its Metadata, htmx:responseError is still triggered
+const HTMX_ERROR_SCRIPT = `<script>document.addEventListener("htmx:beforeSwap",function(e){if(e.detail.xhr.getResponseHeader("` + ERROR_HEADER + `"))e.detail.shouldSwap=true})</script>`
+
+// recoveryWriter records whether the response was started, after which a 500 can no longer be sent
+type recoveryWriter struct {
+	http.ResponseWriter
+	wroteHeader bool
+}
+
+func (w *recoveryWriter) WriteHeader(statusCode int) {
+	w.wroteHeader = true
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *recoveryWriter) Write(bytes []byte) (int, error) {
+	w.wroteHeader = true
+	return w.ResponseWriter.Write(bytes)
+}
+
+func (w *recoveryWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// recoverPanics wraps @handler so a panic, from the user's handlers or a failed render, answers 500 instead of killing the request.
+// The partially rendered buffer is discarded & the stack logged
+func (g Temp) recoverPanics(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rw := &recoveryWriter{ResponseWriter: w}
+
+		defer func() {
+			rec := recover()
+			if rec == nil {
+				return
+			}
+			if rec == http.ErrAbortHandler {
+				panic(rec)
+			}
+
+			g.config.logger().ErrorContext(r.Context(), "handler panic",
+				"request_id", RequestID(r),
+				"path", r.URL.Path,
+				"error", fmt.Sprint(rec),
+				"stack", string(debug.Stack()),
+			)
+
+			if rw.wroteHeader {
+				// too late for a 500, e.g. a streamed page: the error is appended to what was sent, then the response is
+				// aborted so it's never mistaken for, or cached as, a complete 200
+				g.writeStartedError(rw, r, http.StatusInternalServerError)
+				panic(http.ErrAbortHandler)
+			}
+			g.writeError(w, r, http.StatusInternalServerError)
+		}()
+
+		handler(rw, r)
+	}
+}
+
+// writeError answers @status with Config.ErrorComponent, discarding the headers set by the failed handler.
+// htmx requests are retargeted to where the error is swapped in by HTMX_ERROR_SCRIPT
+func (g Temp) writeError(w http.ResponseWriter, r *http.Request, status int) {
+	for key := range w.Header() {
+		if key != http.CanonicalHeaderKey(REQUEST_ID_HEADER) {
+			w.Header().Del(key)
+		}
+	}
+
+	w.Header().Set("Content-Type", HTML_CONTENT_TYPE)
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set(ERROR_HEADER, strconv.Itoa(status))
+	if utils.IsHtmxRequest(r) {
+		setErrorSwapHeaders(w, r)
+	}
+	w.WriteHeader(status)
+
+	err := g.config.errorComponent(r, status).Render(r.Context(), w)
+	if err != nil {
+		g.config.logger().ErrorContext(r.Context(), "error component", "request_id", RequestID(r), "error", err)
+	}
+}
+
+// writeStartedError appends Config.ErrorComponent to a response already started, flushing it before it's aborted
+func (g Temp) writeStartedError(w http.ResponseWriter, r *http.Request, status int) {
+	err := g.config.errorComponent(r, status).Render(r.Context(), w)
+	if err == nil {
+		err = http.NewResponseController(w).Flush()
+	}
+	if err != nil && !errors.Is(err, http.ErrNotSupported) {
+		g.config.logger().ErrorContext(r.Context(), "error component", "request_id", RequestID(r), "error", err)
+	}
+}
+
+// setErrorSwapHeaders swaps the error of a page request where the page would have been, & the error page of any other request into the body
+func setErrorSwapHeaders(w http.ResponseWriter, r *http.Request) {
+	switch determineRequest(r) {
+	case HxGet_Page:
+		// swapped into the target of the element that made the request
+	case HxBoost_Page:
+		setBoostHeaders(w, r)
+	default:
+		w.Header().Set("HX-Retarget", "body")
+		w.Header().Set("HX-Reswap", "innerHTML")
+	}
+}
+
+// DefaultErrorComponent renders a full error page for normal & index requests, a fragment for htmx page requests
+func DefaultErrorComponent(r *http.Request, status int) templ.Component {
+	text := templ.EscapeString(fmt.Sprintf("%d %s", status, http.StatusText(status)))
+
+	return templ.ComponentFunc(func(ctx context.Context, w io.Writer) error {
+		var err error
+		switch determineRequest(r) {
+		case HxGet_Page, HxBoost_Page:
+			_, err = io.WriteString(w, `<div class="temporary-error">`+text+`</div>`)
+		default:
+			_, err = io.WriteString(w, `<!DOCTYPE html><html><head><title>`+text+`</title></head><body><h1>`+text+`</h1></body></html>`)
+		}
+		return err
+	})
+}
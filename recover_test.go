@@ -0,0 +1,32 @@
+package temporary
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRecoverPanicsAfterFlush(t *testing.T) {
+	g := Temp{config: Config{Logger: slog.New(slog.NewTextHandler(io.Discard, nil))}}
+	handler := g.recoverPanics(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "<html><body><main>")
+		panic("boom")
+	})
+
+	w := httptest.NewRecorder()
+	func() {
+		defer func() {
+			if rec := recover(); rec != http.ErrAbortHandler {
+				t.Fatalf("recovered %v, want http.ErrAbortHandler", rec)
+			}
+		}()
+		handler(w, httptest.NewRequest("GET", "/", nil))
+	}()
+
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "500 Internal Server Error") {
+		t.Fatalf("response = %d %q, want the error appended to the started 200", w.Code, w.Body.String())
+	}
+}
@@ -5,264 +5,685 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"html/template"
+	"io/fs"
 	"net/http"
 	"os"
 	"path/filepath"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 
 	"calebsideras.com/temporary/temporary/utils"
 	"github.com/a-h/templ"
 )
 
-// Render() renders all static files defined by the user
-func (g *Temp) Render() {
+// renderJob renders the static file(s) of a single path, @file is the main one
+type renderJob struct {
+	path   string
+	file   string
+	render func() ([]renderedFile, error)
+}
+
+// run renders the job, a panic e.g. in the user's handler fails the job rather than the whole Render()
+func (job renderJob) run() (files []renderedFile, err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			files, err = nil, &RenderFailure{job.path, job.file, fmt.Errorf("panic: %v\n%s", rec, debug.Stack())}
+		}
+	}()
+	return job.render()
+}
+
+// renderedFile is an output file produced, or skipped as unchanged, by a renderJob
+type renderedFile struct {
+	ManifestEntry
+	Skipped bool
+}
+
+func newRenderedFile(path string, file string, fp string, content []byte, gzip bool, response *RecordedResponse, suspense []utils.SuspenseBoundary) renderedFile {
+	entry := ManifestEntry{
+		Path:        path,
+		File:        file,
+		ETag:        utils.GenerateETag(string(content)),
+		Size:        len(content),
+		RenderedAt:  time.Now().UTC(),
+		ContentType: HTML_CONTENT_TYPE,
+		Fingerprint: fp,
+		Response:    response,
+		Suspense:    suspense,
+	}
+	if gzip {
+		entry.Encodings = []string{GZIP_ENCODING}
+	}
+	return renderedFile{entry, false}
+}
+
+// renderState is shared by all the render jobs of a single Render()
+type renderState struct {
+	fsys              fs.FS
+	previous          map[string]ManifestEntry
+	dependency        string
+	indexFingerprints map[string]string
+	indexResponses    map[string]*RecordedResponse // recorded by the handlers of an index.html & its parents, replayed with its page.html
+	failedIndexes     utils.StringSet
+	force             bool
+	sourceHashes      sync.Map
+}
+
+// skip returns the previous render of @file if its @fp is unchanged & the file (and its encodings) still exist
+func (s *renderState) skip(file string, fp string) (renderedFile, bool) {
+	if s.force || fp == "" {
+		return renderedFile{}, false
+	}
+
+	prev, ok := s.previous[file]
+	if !ok || prev.Fingerprint != fp {
+		return renderedFile{}, false
+	}
+
+	for _, f := range prev.encodingFiles() {
+		if _, err := fs.Stat(s.fsys, outputPath(f)); err != nil {
+			return renderedFile{}, false
+		}
+	}
+
+	return renderedFile{prev, true}, true
+}
+
+// Render() renders all static files defined by the user & describes them in the render manifest loaded by Run().
+// Every index.html is rendered before the pages, as static pages are built on top of it.
+// Outputs whose fingerprint (handler source, index & dependency) is unchanged since the last Render() are skipped.
+// If any fails, the others are still rendered & the manifest describes the outputs on disk, previous or rewritten
+func (g *Temp) Render() error {
 
 	fmt.Println("------------------------RENDERING STATIC FILES-------------------------")
 
-	r, _ := http.NewRequest("GET", "/", nil)
-	w := DummyResponseWriter{}
+	workers := g.config.renderWorkers()
+
+	fsys := outputFS()
+
+	manifest, err := loadManifest(fsys)
+	if err != nil {
+		return newRenderError([]error{err})
+	}
+
+	previous := manifest.entries()
+	if manifest != nil && manifest.Version != MANIFEST_VERSION {
+		// outputs of an older format are re-rendered
+		previous = make(map[string]ManifestEntry)
+	}
+
+	state := &renderState{
+		fsys:              fsys,
+		previous:          previous,
+		dependency:        dependencySnapshot(g.dependency),
+		indexFingerprints: make(map[string]string),
+		indexResponses:    make(map[string]*RecordedResponse),
+		failedIndexes:     utils.NewStringSet(),
+		force:             g.config.ForceRender,
+	}
+
+	// sorted so the render order is deterministic
+	indexPaths := make([]string, 0, len(Index))
+	for path := range Index {
+		indexPaths = append(indexPaths, path)
+	}
+	sort.Strings(indexPaths)
+
+	// nested indexes are rendered inside the index.html of their parent, so one depth after another
+	var depths [][]string
+	for _, path := range indexPaths {
+		indexProps := Index[path]
+		state.indexFingerprints[path] = fingerprint(state.handlerSourceHash(indexProps.Handler), state.dependency, strings.Join(indexProps.Metadata, "\n"), g.config.minifyKey(path))
+
+		chain := layoutChain(path)
+		if len(chain) > 1 {
+			// parents sort before their children
+			state.indexFingerprints[path] = fingerprint(state.indexFingerprints[path], state.indexFingerprints[chain[len(chain)-2]])
+		}
+
+		for len(depths) < len(chain) {
+			depths = append(depths, nil)
+		}
+		depths[len(chain)-1] = append(depths[len(chain)-1], path)
+	}
+
+	var indexFiles []renderedFile
+	var errs []error
+	for _, paths := range depths {
+		var indexJobs []renderJob
+		for _, path := range paths {
+			indexJobs = append(indexJobs, g.indexRenderJob(path, Index[path], state))
+		}
+
+		files, depthErrs := runRenderJobs(workers, indexJobs)
+		indexFiles = append(indexFiles, files...)
+		errs = append(errs, depthErrs...)
+
+		for i, err := range depthErrs {
+			if err != nil {
+				state.failedIndexes.Add(paths[i])
+			}
+		}
+
+		// read by the next depth & the pages, once this one is rendered
+		for _, f := range files {
+			if f.File == filepath.Join(f.Path, INDEX_OUT_FILE) {
+				state.indexResponses[f.Path] = f.Response
+			}
+		}
+	}
+
+	var jobs []renderJob
+	for _, pageProps := range PageStatic {
+		jobs = append(jobs, withCacheControl(g.pageRenderJob(pageProps, state), g.config.cacheControl(pageProps.Path, pageProps.Cache)))
+	}
+	for _, routeProps := range RouteStatic {
+		jobs = append(jobs, withCacheControl(g.routeRenderJob(routeProps, state), g.config.cacheControl(routeProps.Path, routeProps.Cache)))
+	}
 
-	output := ""
-	for path, indexProps := range Index {
+	files, jobErrs := runRenderJobs(workers, jobs)
+	errs = append(errs, jobErrs...)
 
-		fmt.Println("Directory:", path)
-		fmt.Println("   -", INDEX_OUT_FILE)
+	files = append(indexFiles, files...)
 
-		fp, err := utils.CreateFile(filepath.Join(path, INDEX_OUT_FILE), HTML_OUT_DIR)
-		defer fp.Close()
+	renderErr := newRenderError(errs)
+	if renderErr != nil {
+		// the previous manifest, updated with the files rewritten before the failure so it still describes every output
+		for _, f := range files {
+			previous[f.File] = f.ManifestEntry
+		}
 
+		err = writeManifest(previous)
 		if err != nil {
-			panic(err)
+			return newRenderError(append(errs, err))
+		}
+		return renderErr
+	}
+
+	current := make(map[string]ManifestEntry)
+	rendered, skipped := 0, 0
+	for _, f := range files {
+		if f.Skipped {
+			skipped++
+		} else {
+			rendered++
 		}
+		current[f.File] = f.ManifestEntry
+	}
+
+	err = writeManifest(current)
+	if err != nil {
+		return newRenderError([]error{err})
+	}
+
+	deleted, err := removeStaleOutputs(previous, current)
+
+	fmt.Printf("Rendered: %d, Skipped: %d, Deleted: %d\n", rendered, skipped, len(deleted))
+	for _, file := range deleted {
+		fmt.Println("   - deleted", file)
+	}
+
+	if err != nil {
+		return newRenderError([]error{err})
+	}
+
+	if g.config.ExportDir != "" {
+		return g.export(g.config.ExportDir)
+	}
+	return nil
+}
+
+// RenderOrExit() calls Render() and exits with a non-zero status code if any static file failed to render
+func (g *Temp) RenderOrExit() {
+	err := g.Render()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// runRenderJobs runs @jobs on at most @workers goroutines. Results are returned in the same order as @jobs
+func runRenderJobs(workers int, jobs []renderJob) ([]renderedFile, []error) {
+
+	results := make([][]renderedFile, len(jobs))
+	errs := make([]error, len(jobs))
+
+	queue := make(chan int)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range queue {
+				results[j], errs[j] = jobs[j].run()
+			}
+		}()
+	}
+
+	for j := range jobs {
+		queue <- j
+	}
+	close(queue)
+
+	wg.Wait()
+
+	var files []renderedFile
+	for _, result := range results {
+		files = append(files, result...)
+	}
+
+	return files, errs
+}
+
+// withCacheControl records the Cache-Control policy the files of @job are served with in the manifest, skipped files included
+func withCacheControl(job renderJob, cacheControl string) renderJob {
+	render := job.render
+	job.render = func() ([]renderedFile, error) {
+		files, err := render()
+		for i := range files {
+			files[i].CacheControl = cacheControl
+		}
+		return files, err
+	}
+	return job
+}
+
+func (g *Temp) indexRenderJob(path string, indexProps IndexProps, state *renderState) renderJob {
+	return renderJob{path, filepath.Join(path, INDEX_OUT_FILE), func() ([]renderedFile, error) {
+
+		file := filepath.Join(path, INDEX_OUT_FILE)
+		bodyFile := filepath.Join(path, INDEX_BODY_OUT_FILE)
+		fp := state.indexFingerprints[path]
+
+		// a nested index is also rendered on its own (index-body.html), swapped into its parent by boosted navigations
+		chain := layoutChain(path)
+		nested := len(chain) > 1
+
+		if prev, ok := state.skip(file, fp); ok {
+			if !nested {
+				return []renderedFile{prev}, nil
+			}
+			if prevBody, ok := state.skip(bodyFile, fp); ok {
+				return []renderedFile{prev, prevBody}, nil
+			}
+		}
+
+		if nested && state.failedIndexes.Contains(chain[len(chain)-2]) {
+			return nil, &RenderFailure{path, file, fmt.Errorf("parent index: %s failed to render", chain[len(chain)-2])}
+		}
+
+		fmt.Printf("Directory: %s\n   - %s\n", path, INDEX_OUT_FILE)
+
+		r, _ := http.NewRequest("GET", "/", nil)
+		w := NewRecordingResponseWriter()
 
 		templOut, err := g.invokeHandlerFunction(indexProps.ParamType, indexProps.Handler, w, r)
 		if err != nil {
-			continue
+			return nil, &RenderFailure{path, file, err}
 		}
 
+		ctx, suspense := utils.NewSuspenseRecorder(context.Background(), r)
+
 		var buffer bytes.Buffer
 
-		err = templOut.Render(templ.WithChildren(context.Background(), utils.PageTemplate()), &buffer)
+		err = templOut.Render(templ.WithChildren(ctx, utils.PageTemplate()), &buffer)
 		if err != nil {
-			panic(err)
+			return nil, &RenderFailure{path, file, err}
 		}
 
-		metadata := convertStringListToBytesBuffer(indexProps.Metadata)
+		// every output is rendered before any is written, so a failed render leaves the previous ones as they are
+		var body []byte
+		response := w.Recorded()
+		bodyResponse := response
 
-		addMetadataIntoBuffer(&buffer, metadata)
+		if nested {
+			body = g.config.minifyHTML(path, buffer.Bytes())
 
-		_, err = fp.Write(buffer.Bytes())
-		if err != nil {
-			panic(err)
+			composed, err := renderNestedIndex(state.fsys, chain[len(chain)-2], body)
+			if err != nil {
+				return nil, &RenderFailure{path, file, err}
+			}
+
+			buffer.Reset()
+			buffer.Write(composed)
+
+			response = mergeRecordedResponses(state.indexResponses[chain[len(chain)-2]], response)
 		}
 
-		// pathAndTagPage, err := readFileAndGenerateETag(HTML_OUT_DIR, filepath.Join(path, PAGE_OUT_FILE))
-		// if err != nil {
-		// 	panic(err)
-		// }
+		metadata := convertStringListToBytesBuffer(indexProps.Metadata)
 
-		// output += pathAndTagPage
+		addMetadataIntoBuffer(&buffer, metadata)
 
-	}
+		content := g.config.minifyHTML(path, buffer.Bytes())
 
-	for _, pageProps := range PageStatic {
+		var files []renderedFile
+
+		if nested {
+			fmt.Println("   -", INDEX_BODY_OUT_FILE)
 
-		fmt.Println("Directory:", pageProps.Path)
-		fmt.Println("   -", PAGE_OUT_FILE)
+			err = writeOutputFile(bodyFile, body)
+			if err != nil {
+				return nil, &RenderFailure{path, bodyFile, err}
+			}
 
-		f, err := utils.CreateFile(filepath.Join(pageProps.Path, PAGE_OUT_FILE), HTML_OUT_DIR)
+			files = append(files, newRenderedFile(path, bodyFile, fp, body, false, bodyResponse, suspense.Boundaries()))
+		}
+
+		err = writeOutputFile(file, content)
 		if err != nil {
-			panic(err)
+			return files, &RenderFailure{path, file, err}
 		}
-		defer f.Close()
+
+		return append(files, newRenderedFile(path, file, fp, content, false, response, suspense.Boundaries())), nil
+	}}
+}
+
+func (g *Temp) pageRenderJob(pageProps PageProps, state *renderState) renderJob {
+	return renderJob{pageProps.Path, filepath.Join(pageProps.Path, PAGE_OUT_FILE), func() ([]renderedFile, error) {
+
+		pageFile := filepath.Join(pageProps.Path, PAGE_OUT_FILE)
+		bodyFile := filepath.Join(pageProps.Path, PAGE_BODY_OUT_FILE)
+		bodyMetaFile := filepath.Join(pageProps.Path, PAGE_BODY_OUT_FILE_W_METADATA)
 
 		indexPath, ok := PathToIndex[pageProps.Path]
 		if !ok {
-			panic(fmt.Errorf("Could not find an index for path: %s", pageProps.Path))
+			return nil, &RenderFailure{pageProps.Path, pageFile, errors.New("could not find an index for path")}
 		}
 
 		indexProps, ok := Index[indexPath]
 		if !ok {
-			panic(fmt.Errorf("Could not find an index for indexKey: %s derived from path: %s", indexPath, pageProps.Path))
+			return nil, &RenderFailure{pageProps.Path, pageFile, fmt.Errorf("could not find an index for indexKey: %s", indexPath)}
 		}
 
-		pageOut, err := g.invokeHandlerFunction(pageProps.ParamType, pageProps.Handler, w, r)
-		if err != nil {
-			panic(fmt.Errorf("Error invoking page.go func from path: %s", pageProps.Path))
+		if state.failedIndexes.Contains(indexPath) {
+			return nil, &RenderFailure{pageProps.Path, pageFile, fmt.Errorf("index: %s failed to render", indexPath)}
 		}
 
-		metadata := convertStringListToBytesBuffer(pageProps.Metadata)
+		bodyFp := fingerprint(state.handlerSourceHash(pageProps.Handler), state.dependency, strings.Join(pageProps.Metadata, "\n"), g.config.minifyKey(pageProps.Path))
+		pageFp := fingerprint(bodyFp, state.indexFingerprints[indexPath])
 
-		// page.html
-		switch indexProps.HandleType {
-		case IndexRender:
-			// parse ALREADY rendered index static file
-			dir := filepath.Clean(filepath.Join(HTML_OUT_DIR, indexPath, INDEX_OUT_FILE))
-			indexTpl, err := template.ParseFiles(dir)
-			if err != nil {
-				panic(fmt.Errorf("Error parsing index.html from path: %s\n%v", dir, err))
-			}
+		var files []renderedFile
 
-			// convert page templ.Component to template.HTML
-			pageTpl, err := templ.ToGoHTML(context.Background(), pageOut)
-			if err != nil {
-				panic(fmt.Errorf("Error converting page.go output from path: %s to template.HTML\n%v", pageProps.Path, err))
-			}
+		prevPage, skipPage := state.skip(pageFile, pageFp)
+		if indexProps.HandleType != IndexRender {
+			// page.html only exists for pre-rendered indexes
+			skipPage = true
+		} else if skipPage {
+			files = append(files, prevPage)
+		}
 
-			// parse & execute
-			_, err = indexTpl.New("page").Parse(string(pageTpl))
+		prevBody, skipBody := state.skip(bodyFile, bodyFp)
+		prevBodyMeta, skipBodyMeta := state.skip(bodyMetaFile, bodyFp)
+		skipBody = skipBody && skipBodyMeta
+		if skipBody {
+			files = append(files, prevBody, prevBodyMeta)
+		}
 
-			if err != nil {
-				panic(fmt.Errorf("Error converting page.go output from path: %s to template.HTML\n%v", pageProps.Path, err))
-			}
+		if skipPage && skipBody {
+			return files, nil
+		}
+
+		fmt.Printf("Directory: %s\n", pageProps.Path)
+
+		r, _ := http.NewRequest("GET", "/", nil)
+		w := NewRecordingResponseWriter()
+
+		pageOut, err := g.invokeHandlerFunction(pageProps.ParamType, pageProps.Handler, w, r)
+		if err != nil {
+			return nil, &RenderFailure{pageProps.Path, pageFile, err}
+		}
 
-			var buffer bytes.Buffer
+		ctx, suspense := utils.NewSuspenseRecorder(context.Background(), r)
 
-			err = indexTpl.Execute(&buffer, nil)
+		// every output is rendered before any is written, so a failed render leaves the previous ones as they are
+		var content, body, bodyMeta []byte
 
+		if !skipPage {
+			content, err = renderFullPage(ctx, state.fsys, pageOut, pageProps, indexPath)
 			if err != nil {
-				panic(fmt.Errorf("Error executing buffer of path: %s\n%v", pageProps.Path, err))
+				return nil, &RenderFailure{pageProps.Path, pageFile, err}
 			}
+			content = g.config.minifyHTML(pageProps.Path, content)
+		}
 
-			addMetadataIntoBuffer(&buffer, metadata)
+		if !skipBody {
+			body, bodyMeta, err = renderPageBody(ctx, pageOut, pageProps)
+			if err != nil {
+				return nil, &RenderFailure{pageProps.Path, bodyFile, err}
+			}
+			body = g.config.minifyHTML(pageProps.Path, body)
+			bodyMeta = g.config.minifyHTML(pageProps.Path, bodyMeta)
+		}
 
-			_, err = f.Write(buffer.Bytes())
+		// page.html
+		if !skipPage {
+			fmt.Println("   -", PAGE_OUT_FILE)
+
+			err = writeCompressedOutputFile(pageFile, content)
 			if err != nil {
-				panic(err)
+				return files, &RenderFailure{pageProps.Path, pageFile, err}
 			}
 
+			// the page takes precedence over its index
+			response := mergeRecordedResponses(state.indexResponses[indexPath], w.Recorded())
+			files = append(files, newRenderedFile(pageProps.Path, pageFile, pageFp, content, true, response, suspense.Boundaries()))
 		}
 
-		pathAndTagPage, err := readFileAndGenerateETag(HTML_OUT_DIR, filepath.Join(pageProps.Path, PAGE_OUT_FILE))
-		if err != nil {
-			panic(err)
+		if skipBody {
+			return files, nil
 		}
-		output += pathAndTagPage
 
 		// page-body.html
 		fmt.Println("   -", PAGE_BODY_OUT_FILE)
 
-		fb, err := utils.CreateFile(filepath.Join(pageProps.Path, PAGE_BODY_OUT_FILE), HTML_OUT_DIR)
+		err = writeCompressedOutputFile(bodyFile, body)
 		if err != nil {
-			panic(err)
+			return files, &RenderFailure{pageProps.Path, bodyFile, err}
 		}
-		defer fb.Close()
 
-		var buffer bytes.Buffer
-
-		err = pageOut.Render(context.Background(), &buffer)
-		if err != nil {
-			panic(err)
-		}
-
-		_, err = fb.Write(buffer.Bytes())
-		if err != nil {
-			panic(err)
-		}
+		files = append(files, newRenderedFile(pageProps.Path, bodyFile, bodyFp, body, true, w.Recorded(), suspense.Boundaries()))
 
 		// page-body-metadata.html
 		fmt.Println("   -", PAGE_BODY_OUT_FILE_W_METADATA)
 
-		fbm, err := utils.CreateFile(filepath.Join(pageProps.Path, PAGE_BODY_OUT_FILE_W_METADATA), HTML_OUT_DIR)
+		err = writeCompressedOutputFile(bodyMetaFile, bodyMeta)
 		if err != nil {
-			panic(err)
+			return files, &RenderFailure{pageProps.Path, bodyMetaFile, err}
 		}
-		defer fbm.Close()
 
-		var bufferM bytes.Buffer
+		files = append(files, newRenderedFile(pageProps.Path, bodyMetaFile, bodyFp, bodyMeta, true, w.Recorded(), suspense.Boundaries()))
 
-		pageMetadata := initPageMetadataVar(pageProps.Metadata)
-		bufferM.Write(pageMetadata.Bytes())
+		return files, nil
+	}}
+}
 
-		err = pageOut.Render(context.Background(), &bufferM)
-		if err != nil {
-			panic(err)
-		}
+// renderNestedIndex renders the index @body inside the pre-rendered index.html of its @parent in @fsys
+func renderNestedIndex(fsys fs.FS, parent string, body []byte) ([]byte, error) {
 
-		_, err = fbm.Write(bufferM.Bytes())
-		if err != nil {
-			panic(err)
-		}
+	file := outputPath(filepath.Join(parent, INDEX_OUT_FILE))
+	content, err := fs.ReadFile(fsys, file)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", file, err)
+	}
 
-		pathAndTagBody, err := readFileAndGenerateETag(HTML_OUT_DIR, filepath.Join(pageProps.Path, PAGE_BODY_OUT_FILE_W_METADATA))
-		if err != nil {
-			panic(err)
-		}
-		output += pathAndTagBody
+	layout, err := splitIndexLayout(content)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
 	}
 
-	for _, routeProps := range RouteStatic {
+	var buffer bytes.Buffer
 
-		fmt.Println("Directory:", routeProps.Path)
-		fmt.Println("   -", ROUTE_OUT_FILE)
+	layout.write(&buffer, func(buffer *bytes.Buffer) error {
+		_, err := buffer.Write(body)
+		return err
+	})
 
-		fp, err := utils.CreateFile(filepath.Join(routeProps.Path, ROUTE_OUT_FILE), HTML_OUT_DIR)
-		defer fp.Close()
+	return buffer.Bytes(), nil
+}
 
-		if err != nil {
-			panic(err)
+// renderFullPage renders @pageOut inside the pre-rendered index.html of @indexPath in @fsys, with the page metadata added to <head>
+func renderFullPage(ctx context.Context, fsys fs.FS, pageOut templ.Component, pageProps PageProps, indexPath string) ([]byte, error) {
+
+	// ALREADY rendered index static file
+	file := outputPath(filepath.Join(indexPath, INDEX_OUT_FILE))
+	content, err := fs.ReadFile(fsys, file)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", file, err)
+	}
+
+	layout, err := splitIndexLayout(content)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+
+	var buffer bytes.Buffer
+
+	err = layout.write(&buffer, func(buffer *bytes.Buffer) error {
+		return pageOut.Render(ctx, buffer)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("rendering page.go output: %w", err)
+	}
+
+	addMetadataIntoBuffer(&buffer, convertStringListToBytesBuffer(pageProps.Metadata))
+
+	return buffer.Bytes(), nil
+}
+
+// renderPageBody renders @pageOut on its own (page-body.html) & prefixed by its metadata <head> (page-body-metadata.html)
+func renderPageBody(ctx context.Context, pageOut templ.Component, pageProps PageProps) ([]byte, []byte, error) {
+
+	var buffer bytes.Buffer
+
+	err := pageOut.Render(ctx, &buffer)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var bufferM bytes.Buffer
+
+	pageMetadata := initPageMetadataVar(pageProps.Metadata)
+	bufferM.Write(pageMetadata.Bytes())
+	bufferM.Write(buffer.Bytes())
+
+	return buffer.Bytes(), bufferM.Bytes(), nil
+}
+
+func (g *Temp) routeRenderJob(routeProps RouteProps, state *renderState) renderJob {
+	return renderJob{routeProps.Path, filepath.Join(routeProps.Path, ROUTE_OUT_FILE), func() ([]renderedFile, error) {
+
+		file := filepath.Join(routeProps.Path, ROUTE_OUT_FILE)
+		fp := fingerprint(state.handlerSourceHash(routeProps.Handler), state.dependency, g.config.minifyKey(routeProps.Path))
+
+		if prev, ok := state.skip(file, fp); ok {
+			return []renderedFile{prev}, nil
 		}
 
+		fmt.Printf("Directory: %s\n   - %s\n", routeProps.Path, ROUTE_OUT_FILE)
+
+		r, _ := http.NewRequest("GET", "/", nil)
+		w := NewRecordingResponseWriter()
+
 		templOut, err := g.invokeHandlerFunction(routeProps.ParamType, routeProps.Handler, w, r)
 		if err != nil {
-			continue
+			return nil, &RenderFailure{routeProps.Path, file, err}
 		}
 
-		err = templOut.Render(context.Background(), fp)
+		ctx, suspense := utils.NewSuspenseRecorder(context.Background(), r)
+
+		var buffer bytes.Buffer
+
+		err = templOut.Render(ctx, &buffer)
 		if err != nil {
-			panic(err)
+			return nil, &RenderFailure{routeProps.Path, file, err}
 		}
 
-		pathAndTagBody, err := readFileAndGenerateETag(HTML_OUT_DIR, filepath.Join(routeProps.Path, ROUTE_OUT_FILE))
+		content := g.config.minifyHTML(routeProps.Path, buffer.Bytes())
+
+		err = writeCompressedOutputFile(file, content)
 		if err != nil {
-			panic(err)
+			return nil, &RenderFailure{routeProps.Path, file, err}
 		}
-		output += pathAndTagBody
 
+		return []renderedFile{newRenderedFile(routeProps.Path, file, fp, content, true, w.Recorded(), suspense.Boundaries())}, nil
+	}}
+}
+
+// writeOutputFile writes @content to @filePath inside HTML_OUT_DIR, closing the file before returning
+func writeOutputFile(filePath string, content []byte) error {
+	return writeFileTo(HTML_OUT_DIR, filePath, content)
+}
+
+// writeCompressedOutputFile writes @content & its gzip compressed .gz sibling, served to clients accepting gzip
+func writeCompressedOutputFile(filePath string, content []byte) error {
+	err := writeOutputFile(filePath, content)
+	if err != nil {
+		return err
+	}
+
+	compressed, err := gzipBytes(content)
+	if err != nil {
+		return err
+	}
+
+	return writeOutputFile(filePath+GZ_EXT, compressed)
+}
+
+func writeFileTo(outDir string, filePath string, content []byte) error {
+	fp, err := utils.CreateFile(filePath, outDir)
+	if err != nil {
+		return err
 	}
 
-	file, err := utils.CreateFile(ETAG_FILE, HTML_OUT_DIR)
-	defer file.Close()
+	_, err = fp.Write(content)
 	if err != nil {
-		panic(err)
+		fp.Close()
+		return err
 	}
 
-	_, err = file.Write([]byte(output))
+	return fp.Close()
+}
+
+// writeFileAtomic writes @content to a temporary file and renames it over @filePath, so readers never see a partial file
+func writeFileAtomic(filePath string, content []byte) error {
+	tmpPath := filePath + ".tmp"
+
+	err := writeOutputFile(tmpPath, content)
 	if err != nil {
-		panic(err)
+		os.Remove(filepath.Join(HTML_OUT_DIR, tmpPath))
+		return err
 	}
 
+	return os.Rename(filepath.Join(HTML_OUT_DIR, tmpPath), filepath.Join(HTML_OUT_DIR, filePath))
 }
 
 // TODO: needs to be generated
-func (g Temp) invokeHandlerFunction(params ParamType, fn interface{}, w DummyResponseWriter, r *http.Request) (templ.Component, error) {
+func (g Temp) invokeHandlerFunction(params ParamType, fn interface{}, w http.ResponseWriter, r *http.Request) (templ.Component, error) {
 
-	var templOut templ.Component
 	switch params {
 	case def:
-		templOut = fn.(func() templ.Component)()
+		if handler, ok := fn.(func() templ.Component); ok {
+			return handler(), nil
+		}
 	case dep:
-		templOut = fn.(func(d interface{}) templ.Component)(g.dependency)
+		if handler, ok := fn.(func(d interface{}) templ.Component); ok {
+			return handler(g.dependency), nil
+		}
 	case resReq:
-		templOut = fn.(func(w http.ResponseWriter, r *http.Request) templ.Component)(w, r)
+		if handler, ok := fn.(func(w http.ResponseWriter, r *http.Request) templ.Component); ok {
+			return handler(w, r), nil
+		}
 	case resReqDep:
-		templOut = fn.(func(w http.ResponseWriter, r *http.Request, d interface{}) templ.Component)(w, r, g.dependency)
-	case paramErr:
-		return nil, errors.New(fmt.Sprintf("something"))
+		if handler, ok := fn.(func(w http.ResponseWriter, r *http.Request, d interface{}) templ.Component); ok {
+			return handler(w, r, g.dependency), nil
+		}
 	default:
-		return nil, errors.New(fmt.Sprintf("something"))
-	}
-
-	return templOut, nil
-}
-
-func readFileAndGenerateETag(outDir string, filePath string) (string, error) {
-
-	content, err := os.ReadFile(filepath.Join(outDir, filePath))
-	if err != nil {
-		return "", err
+		return nil, fmt.Errorf("unsupported handler params: %d", params)
 	}
-	output := fmt.Sprintf("%s:%s\n", filePath, utils.GenerateETag(string(content)))
-	return output, nil
 
+	return nil, fmt.Errorf("handler of type %T doesn't match its params: %d", fn, params)
 }
@@ -0,0 +1,65 @@
+package temporary
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// RenderFailure describes a single static file that could not be rendered
+type RenderFailure struct {
+	Path string // route path defined by the user
+	File string // output file, relative to HTML_OUT_DIR
+	Err  error
+}
+
+func (f *RenderFailure) Error() string {
+	return fmt.Sprintf("%s (%s): %v", f.Path, f.File, f.Err)
+}
+
+func (f *RenderFailure) Unwrap() error {
+	return f.Err
+}
+
+// RenderError is returned by Render() when one or more static files could not be rendered
+type RenderError struct {
+	Failures []*RenderFailure
+}
+
+func (e *RenderError) Error() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "failed to render %d static file(s):", len(e.Failures))
+	for _, f := range e.Failures {
+		sb.WriteString("\n   - ")
+		sb.WriteString(f.Error())
+	}
+	return sb.String()
+}
+
+func (e *RenderError) Unwrap() []error {
+	errs := make([]error, len(e.Failures))
+	for i, f := range e.Failures {
+		errs[i] = f
+	}
+	return errs
+}
+
+// newRenderError collects the non-nil @errs into a *RenderError, returns nil if there are none
+func newRenderError(errs []error) error {
+	var failures []*RenderFailure
+	for _, err := range errs {
+		if err == nil {
+			continue
+		}
+		var failure *RenderFailure
+		if !errors.As(err, &failure) {
+			failure = &RenderFailure{Err: err}
+		}
+		failures = append(failures, failure)
+	}
+
+	if len(failures) == 0 {
+		return nil
+	}
+	return &RenderError{failures}
+}
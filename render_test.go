@@ -0,0 +1,130 @@
+package temporary
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"calebsideras.com/temporary/temporary/utils"
+	"github.com/a-h/templ"
+)
+
+func indexComponent() templ.Component {
+	return templ.ComponentFunc(func(ctx context.Context, w io.Writer) error {
+		_, err := io.WriteString(w, "<html><head></head><body>")
+		if err != nil {
+			return err
+		}
+		err = templ.GetChildren(ctx).Render(ctx, w)
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(w, "</body></html>")
+		return err
+	})
+}
+
+// setupRender renders into a temporary directory, with a single pre-rendered index at / for @pages
+func setupRender(t *testing.T, pages ...PageProps) {
+	dir := t.TempDir()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.Chdir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	Index = map[string]IndexProps{"/": {Path: "/", Handler: indexComponent, ParamType: def, HandleType: IndexRender}}
+	PathToIndex = map[string]string{"/": "/"}
+	for _, page := range pages {
+		PathToIndex[page.Path] = "/"
+	}
+	PageStatic = pages
+	PageDynamic, RouteStatic, RouteDynamic = nil, nil, nil
+
+	t.Cleanup(func() {
+		os.Chdir(wd)
+		Index, PathToIndex, PageStatic = nil, nil, nil
+	})
+}
+
+func staticPage(path string, handler func() templ.Component) PageProps {
+	return PageProps{Path: path, Handler: handler, ParamType: def}
+}
+
+func TestRenderRecoversHandlerPanic(t *testing.T) {
+	setupRender(t,
+		staticPage("/ok", func() templ.Component { return rawComponent("<p>ok</p>") }),
+		staticPage("/boom", func() templ.Component { panic("boom") }),
+	)
+
+	err := (&Temp{}).Render()
+
+	var renderErr *RenderError
+	if !errors.As(err, &renderErr) {
+		t.Fatalf("Render() error = %v, want a *RenderError", err)
+	}
+	if len(renderErr.Failures) != 1 || renderErr.Failures[0].Path != "/boom" {
+		t.Fatalf("Render() failures = %v, want /boom only", renderErr.Failures)
+	}
+
+	_, err = os.Stat(filepath.Join(HTML_OUT_DIR, "ok", PAGE_BODY_OUT_FILE))
+	if err != nil {
+		t.Fatalf("the other page wasn't rendered: %v", err)
+	}
+}
+
+func TestRenderMismatchedHandler(t *testing.T) {
+	setupRender(t, PageProps{Path: "/a", Handler: func(string) templ.Component { return nil }, ParamType: def})
+
+	var renderErr *RenderError
+	if err := (&Temp{}).Render(); !errors.As(err, &renderErr) {
+		t.Fatalf("Render() error = %v, want a *RenderError", err)
+	}
+}
+
+func TestRenderFailureKeepsManifestInSync(t *testing.T) {
+	version := "v1"
+	fail := false
+	setupRender(t,
+		staticPage("/a", func() templ.Component { return rawComponent("<p>" + version + "</p>") }),
+		staticPage("/b", func() templ.Component {
+			if fail {
+				panic("boom")
+			}
+			return rawComponent("<p>b</p>")
+		}),
+	)
+
+	err := (&Temp{}).Render()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// /a is rewritten while /b fails
+	version, fail = "v2", true
+	err = (&Temp{}).Render()
+	if err == nil {
+		t.Fatal("Render() error = nil, want /b to fail")
+	}
+
+	manifest, err := loadManifest(outputFS())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for file, entry := range manifest.entries() {
+		content, err := os.ReadFile(filepath.Join(HTML_OUT_DIR, file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if entry.ETag != utils.GenerateETag(string(content)) || entry.Size != len(content) {
+			t.Errorf("manifest entry of %s doesn't describe its file: %q", file, content)
+		}
+	}
+}
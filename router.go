@@ -0,0 +1,113 @@
+package temporary
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// Router registers the pages & routes served by Handler(). Use ServeMuxRouter for net/http or muxrouter.New for gorilla/mux
+type Router interface {
+	http.Handler
+	// HandlePath registers @handler for a path defined by the user e.g. /docs/{slug}, with or without a trailing slash
+	HandlePath(path string, handler http.HandlerFunc) error
+}
+
+type pathParamsKey struct{}
+
+// PathParams returns the slug values of the path matched by @r e.g. /docs/{slug} -> {"slug": "intro"}
+func PathParams(r *http.Request) map[string]string {
+	params, _ := r.Context().Value(pathParamsKey{}).(map[string]string)
+	return params
+}
+
+// PathParam returns the value of the slug @name in the path matched by @r, "" if there is none
+func PathParam(r *http.Request, name string) string {
+	return PathParams(r)[name]
+}
+
+// WithPathParams makes @params available to the handlers of @r through PathParams(), for Router implementations
+func WithPathParams(r *http.Request, params map[string]string) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), pathParamsKey{}, params))
+}
+
+// pathParamNames returns the slug names of @path e.g. /docs/{slug}/{id:[0-9]+} -> [slug id]
+func pathParamNames(path string) []string {
+	var names []string
+	for _, match := range slugRegex.FindAllStringSubmatch(path, -1) {
+		name, _, _ := strings.Cut(match[1], ":")
+		names = append(names, name)
+	}
+	return names
+}
+
+type serveMuxRouter struct {
+	*http.ServeMux
+}
+
+// ServeMuxRouter adapts a net/http ServeMux, using the patterns introduced in Go 1.22 e.g. /docs/{slug}
+func ServeMuxRouter(m *http.ServeMux) Router {
+	return serveMuxRouter{m}
+}
+
+// HandlePath registers @path with the regex of its slugs removed, as ServeMux doesn't support them e.g. /posts/{id:[0-9]+} -> /posts/{id}.
+// The regexes are checked by the handler instead, answering 404 if a value doesn't match
+func (m serveMuxRouter) HandlePath(path string, handler http.HandlerFunc) (err error) {
+	pattern, constraints, err := serveMuxPattern(path)
+	if err != nil {
+		return fmt.Errorf("Error registering path %s\n%v", path, err)
+	}
+
+	names := pathParamNames(path)
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		for name, regex := range constraints {
+			if !regex.MatchString(r.PathValue(name)) {
+				http.NotFound(w, r)
+				return
+			}
+		}
+
+		params := make(map[string]string)
+		for _, name := range names {
+			params[name] = r.PathValue(name)
+		}
+		handler(w, WithPathParams(r, params))
+	}
+
+	// ServeMux panics on invalid or conflicting patterns
+	defer func() {
+		if rec := recover(); rec != nil {
+			err = fmt.Errorf("Error registering path %s\n%v", path, rec)
+		}
+	}()
+
+	// {$} matches the path exactly, rather than every path below it
+	pattern = strings.TrimSuffix(pattern, "/")
+	if pattern != "" {
+		m.HandleFunc(pattern, fn)
+	}
+	m.HandleFunc(pattern+"/{$}", fn)
+	return nil
+}
+
+// serveMuxPattern returns @path without the regex of its slugs & the compiled regex of each constrained slug
+func serveMuxPattern(path string) (string, map[string]*regexp.Regexp, error) {
+	constraints := make(map[string]*regexp.Regexp)
+
+	var err error
+	pattern := slugRegex.ReplaceAllStringFunc(path, func(slug string) string {
+		name, expr, ok := strings.Cut(slug[1:len(slug)-1], ":")
+		if ok {
+			regex, compileErr := regexp.Compile("^(?:" + expr + ")$")
+			if compileErr != nil {
+				err = compileErr
+			}
+			constraints[name] = regex
+		}
+		return "{" + name + "}"
+	})
+
+	return pattern, constraints, err
+}
@@ -1,17 +1,18 @@
 package temporary
 
 import (
-	"bufio"
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
-	"log"
 	"net/http"
 	"os"
+	"os/signal"
 	"path/filepath"
-	"strings"
+	"syscall"
+	"time"
 
 	"calebsideras.com/temporary/temporary/utils"
-	"github.com/gorilla/mux"
 )
 
 type requestType int64
@@ -23,170 +24,422 @@ const (
 	HxBoost_Page
 	HxBoost_Index
 	ErrorRequest
+	HxHistory_Restore
+	HxBoost_Layout
 )
 
+const (
+	BOOST_RETARGET = "global main"
+	BOOST_RESWAP   = "innerHTML transition:true"
+)
+
+// DEFAULT_CACHE_CONTROL revalidates every response of a page or route without a cache policy
+const DEFAULT_CACHE_CONTROL = "no-cache"
+
+// VARY_HEADERS are the request headers determineRequest() reads, the same URL answers a full page or a partial depending on them
+var VARY_HEADERS = []string{"HX-Request", "HX-Boosted", "HX-Current-URL", "HX-History-Restore-Request", "Accept-Encoding"}
+
 type pageHandler func(w http.ResponseWriter, r *http.Request)
 
-func (t *Temp) Run(r *mux.Router, port string) {
+// Handler registers every page & route on @r, a new ServeMuxRouter if nil, & returns it to be served by any http.Server
+func (t *Temp) Handler(r Router) (http.Handler, error) {
 	fmt.Println("----------------------------CREATING HANDLERS----------------------------")
-	http.Handle("/", r)
-	t.handleRoutes(r, t.getETags())
-	log.Fatal(http.ListenAndServe(port, nil))
+
+	if r == nil {
+		r = ServeMuxRouter(http.NewServeMux())
+	}
+
+	cache, err := loadStaticCache(t.config.outputFS(), t.config.DevMode)
+	if err != nil {
+		return nil, fmt.Errorf("Could not load pre-rendered files: %w", err)
+	}
+
+	matcher, err := newRouteMatcher(PathToIndex, t.config.BasePath)
+	if err != nil {
+		return nil, fmt.Errorf("Could not compile routes: %w", err)
+	}
+	routes.Store(matcher)
+
+	err = t.handleRoutes(r, cache)
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
 }
 
-func (t *Temp) handleRoutes(r *mux.Router, eTags map[string]string) {
+// Serve serves @srv until @ctx is cancelled, then shuts it down gracefully, waiting for in-flight requests to complete.
+// Uses Handler(nil) if @srv has no handler & TLS if @srv.TLSConfig has certificates
+func (t *Temp) Serve(ctx context.Context, srv *http.Server) error {
+	if srv.Handler == nil {
+		handler, err := t.Handler(nil)
+		if err != nil {
+			return err
+		}
+		srv.Handler = handler
+	}
+
+	errs := make(chan error, 1)
+	go func() {
+		if srv.TLSConfig != nil && (len(srv.TLSConfig.Certificates) > 0 || srv.TLSConfig.GetCertificate != nil) {
+			errs <- srv.ListenAndServeTLS("", "")
+		} else {
+			errs <- srv.ListenAndServe()
+		}
+	}()
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), t.config.shutdownTimeout())
+	defer cancel()
+
+	err := srv.Shutdown(shutdownCtx)
+	if err != nil {
+		return err
+	}
+
+	err = <-errs
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+// Run mounts @r at / of http.DefaultServeMux & serves it on @port until interrupted (SIGINT/SIGTERM), then shuts down gracefully.
+// Handlers registered on http.DefaultServeMux before Run() are still served, use Handler() & Serve() to opt out
+func (t *Temp) Run(r Router, port string) error {
+	handler, err := t.Handler(r)
+	if err != nil {
+		return err
+	}
+
+	err = mountDefaultServeMux(handler)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	return t.Serve(ctx, &http.Server{Addr: port, Handler: http.DefaultServeMux})
+}
+
+// mountDefaultServeMux registers @handler for every path of http.DefaultServeMux not registered by the user
+func mountDefaultServeMux(handler http.Handler) (err error) {
+	// already registered on it, e.g. ServeMuxRouter(http.DefaultServeMux)
+	if m, ok := handler.(serveMuxRouter); ok && m.ServeMux == http.DefaultServeMux {
+		return nil
+	}
+
+	// ServeMux panics if / is already registered
+	defer func() {
+		if rec := recover(); rec != nil {
+			err = fmt.Errorf("Error mounting the router on http.DefaultServeMux\n%v", rec)
+		}
+	}()
+
+	http.Handle("/", handler)
+	return nil
+}
+
+func (t *Temp) handleRoutes(r Router, cache *staticCache) error {
 	fmt.Println("Function Type: Page - Static")
-	t.setPageStatic(r, eTags)
+	err := t.setPageStatic(r, cache)
+	if err != nil {
+		return err
+	}
 	fmt.Println("Function Type: Page - Dynamic")
-	t.setPageDynamic(r, eTags)
+	err = t.setPageDynamic(r, cache)
+	if err != nil {
+		return err
+	}
 	fmt.Println("Function Type: Route - Static")
-	t.setRouteStatic(r, eTags)
+	err = t.setRouteStatic(r, cache)
+	if err != nil {
+		return err
+	}
 	fmt.Println("Function Type: Route - Dynamic")
-	t.setRouteDynamic(r, eTags)
+	err = t.setRouteDynamic(r, cache)
+	if err != nil {
+		return err
+	}
+	fmt.Println("Function Type: Suspense")
+	return t.setSuspense(r, cache)
 }
 
-func (t *Temp) setPageStatic(r *mux.Router, eTags map[string]string) {
+func (t *Temp) setPageStatic(r Router, cache *staticCache) error {
 	for _, pageProps := range PageStatic {
 		// loop variable capture? can be removed if updated to go 1.23?
 		currRoute := pageProps.Path
-		fmt.Printf("   - %s\n", currRoute)
+		cacheControl := t.config.cacheControl(currRoute, pageProps.Cache)
+		fmt.Printf("   - %s (%s)\n", currRoute, cacheControl)
 
 		indexProps, err := getIndexPropsFromPage(pageProps)
 		if err != nil {
-			panic(err)
+			return err
 		}
 
-		r.HandleFunc(currRoute+"{slash:/?}", t.setStaticPageHandler(pageProps, indexProps, eTags))
+		handler, err := t.setStaticPageHandler(pageProps, indexProps, cache)
+		if err != nil {
+			return err
+		}
+
+		err = r.HandlePath(currRoute, t.logRequests(currRoute, "static", "page", t.recoverPanics(t.cachePolicy(cacheControl, handler))))
+		if err != nil {
+			return err
+		}
 	}
+	return nil
 }
 
-func (t *Temp) setPageDynamic(r *mux.Router, eTags map[string]string) {
+func (t *Temp) setPageDynamic(r Router, cache *staticCache) error {
 	for _, pageProps := range PageDynamic {
 		// loop variable capture? can be removed if updated to go 1.23?
 		currRoute := pageProps.Path
-		fmt.Printf("   - %s\n", currRoute)
+		cacheControl := t.config.cacheControl(currRoute, pageProps.Cache)
+		fmt.Printf("   - %s (%s)\n", currRoute, cacheControl)
 
 		indexProps, err := getIndexPropsFromPage(pageProps)
 		if err != nil {
-			panic(err)
+			return err
+		}
+
+		var handler http.HandlerFunc
+		if t.config.stream(currRoute) {
+			handler, err = t.setStreamPageHandler(pageProps, indexProps, cache)
+		} else {
+			handler, err = t.setDynamicPageHandler(pageProps, indexProps, cache)
+		}
+		if err != nil {
+			return err
 		}
 
-		r.HandleFunc(currRoute+"{slash:/?}", t.setDynamicPageHandler(pageProps, indexProps, eTags))
+		err = r.HandlePath(currRoute, t.logRequests(currRoute, "dynamic", "page", t.recoverPanics(t.cachePolicy(cacheControl, handler))))
+		if err != nil {
+			return err
+		}
 	}
+	return nil
 }
 
-func (t *Temp) setRouteDynamic(r *mux.Router, eTags map[string]string) {
+func (t *Temp) setRouteDynamic(r Router, cache *staticCache) error {
 	for _, routeProps := range RouteDynamic {
 		currRoute := routeProps
-		fmt.Printf("   - %s\n", currRoute.Path)
+		cacheControl := t.config.cacheControl(currRoute.Path, currRoute.Cache)
+		fmt.Printf("   - %s (%s)\n", currRoute.Path, cacheControl)
+
+		var handler http.HandlerFunc
+		var err error
+		if t.config.stream(currRoute.Path) {
+			handler, err = t.setStreamRouteHandler(routeProps)
+		} else {
+			handler, err = t.setDynamicRouteHandler(routeProps, cache)
+		}
+		if err != nil {
+			return err
+		}
 
-		r.HandleFunc(currRoute.Path+"{slash:/?}", t.setDynamicRouteHandler(routeProps, eTags))
+		err = r.HandlePath(currRoute.Path, t.logRequests(currRoute.Path, "dynamic", "route", t.recoverPanics(t.cachePolicy(cacheControl, handler))))
+		if err != nil {
+			return err
+		}
 	}
-
+	return nil
 }
 
-func (t *Temp) setRouteStatic(r *mux.Router, eTags map[string]string) {
+func (t *Temp) setRouteStatic(r Router, cache *staticCache) error {
 	for _, routeProps := range RouteStatic {
 		currRoute := routeProps
-		fmt.Printf("   - %s\n", currRoute.Path)
+		cacheControl := t.config.cacheControl(currRoute.Path, currRoute.Cache)
+		fmt.Printf("   - %s (%s)\n", currRoute.Path, cacheControl)
 
-		r.HandleFunc(currRoute.Path+"{slash:/?}", t.setStaticRouteHandler(routeProps, eTags))
+		handler, err := t.setStaticRouteHandler(routeProps, cache)
+		if err != nil {
+			return err
+		}
+
+		err = r.HandlePath(currRoute.Path, t.logRequests(currRoute.Path, "static", "route", t.recoverPanics(t.cachePolicy(cacheControl, handler))))
+		if err != nil {
+			return err
+		}
 	}
+	return nil
 }
 
-func (g Temp) setDynamicPageHandler(page PageProps, index IndexProps, eTags map[string]string) http.HandlerFunc {
+func (g Temp) setDynamicPageHandler(page PageProps, index IndexProps, cache *staticCache) (http.HandlerFunc, error) {
 
-	fullPageFn, err := getDynamicFullPageClosure(page, index, index.Path)
+	fullPageFn, err := getDynamicFullPageClosure(page, index, index.Path, cache)
 	if err != nil {
-		panic(fmt.Errorf("Error creating handler for route %s\n%w", page.Path, err))
+		return nil, fmt.Errorf("Error creating handler for route %s\n%w", page.Path, err)
 	}
 
 	partialPageFn, err := getDynamicPageClosure(page, index)
 	if err != nil {
-		panic(fmt.Errorf("Error creating handler for route %s\n%w", page.Path, err))
+		return nil, fmt.Errorf("Error creating handler for route %s\n%w", page.Path, err)
 	}
 
 	partialPageBoostFn := getPartialPageBoostFn(partialPageFn)
 
+	pageFn := userFunctionWrapper(page.Handler, page.ParamType)
+	if pageFn == nil {
+		return nil, fmt.Errorf("Error creating handler for route %s\ninvalid handlerParams", page.Path)
+	}
+
 	return func(w http.ResponseWriter, r *http.Request) {
-		logs := fmt.Sprintf("%s %s %s", r.RemoteAddr, r.Method, r.URL.Path)
+		if determineRequest(r) == HxBoost_Layout {
+			var body bytes.Buffer
+
+			err := pageFn(w, r, g.dependency).Render(r.Context(), &body)
+			if err != nil {
+				panic(fmt.Errorf("Error rendering page.go output from path: %s\n%v", page.Path, err))
+			}
+
+			g.writeLayoutSwap(w, r, page, index, cache, body.Bytes())
+			return
+		}
 
 		var buffer bytes.Buffer
 
 		executeAppropriateFn(w, r, g.dependency, &buffer, partialPageFn, partialPageBoostFn, fullPageFn, fullPageFn)
 
-		eTag := utils.GenerateETag(buffer.String())
-		writeRequest(w, r, eTag, buffer.Bytes(), eTags, logs)
-	}
+		content := g.config.minifyHTML(page.Path, buffer.Bytes())
+
+		eTag := utils.GenerateETag(string(content))
+		g.writeRequest(w, r, eTag, time.Time{}, content, nil)
+	}, nil
 }
 
-func (g Temp) setStaticPageHandler(page PageProps, index IndexProps, eTags map[string]string) http.HandlerFunc {
+func (g Temp) setStaticPageHandler(page PageProps, index IndexProps, cache *staticCache) (http.HandlerFunc, error) {
 
-	fullPageFn, err := getStaticFullPageClosure(page, index, index.Path)
+	fullPageFn, err := getStaticFullPageClosure(page, index, index.Path, cache)
 	if err != nil {
-		panic(fmt.Errorf("Error creating handler for route %s\n%w", page.Path, err))
+		return nil, fmt.Errorf("Error creating handler for route %s\n%w", page.Path, err)
 	}
 
-	partialPageFn, err := getStaticPageClosure(page)
+	partialPageFn, err := getStaticPageClosure(page, cache)
 	if err != nil {
-		panic(fmt.Errorf("Error creating handler for route %s\n%w", page.Path, err))
+		return nil, fmt.Errorf("Error creating handler for route %s\n%w", page.Path, err)
 	}
 
 	partialPageBoostFn := getPartialPageBoostFn(partialPageFn)
 
 	return func(w http.ResponseWriter, r *http.Request) {
-		logs := fmt.Sprintf("%s %s %s", r.RemoteAddr, r.Method, r.URL.Path)
+		if determineRequest(r) == HxBoost_Layout {
+			output, err := cache.get(filepath.Join(page.Path, PAGE_BODY_OUT_FILE))
+			if err != nil {
+				g.handleRenderError(err, w, r)
+				return
+			}
+			markCacheHit(r)
+			w = replayRecordedResponse(w, output.Response)
+
+			g.writeLayoutSwap(w, r, page, index, cache, output.Content)
+			return
+		}
+
+		var output *staticOutput
+		if staticFile := staticPageFile(page, index, r); staticFile != "" {
+			cached, err := cache.get(staticFile)
+			if err != nil {
+				g.handleRenderError(err, w, r)
+				return
+			}
+			output = cached
+			markCacheHit(r)
+			w = replayRecordedResponse(w, output.Response)
+
+			// answered from the manifest, before copying the pre-rendered page
+			eTag := encodingETag(output.ETag, g.responseEncoding(r, output.Size, output.Gzip))
+			if g.writeNotModified(w, r, eTag, output.RenderedAt) {
+				return
+			}
+		}
 
 		var buffer bytes.Buffer
 
 		executeAppropriateFn(w, r, g.dependency, &buffer, partialPageFn, partialPageBoostFn, fullPageFn, fullPageFn)
 
-		eTag := utils.GenerateETag(buffer.String())
-		writeRequest(w, r, eTag, buffer.Bytes(), eTags, logs)
-	}
+		if output == nil {
+			// rendered at runtime by a dynamic index
+			content := g.config.minifyHTML(page.Path, buffer.Bytes())
+			g.writeRequest(w, r, utils.GenerateETag(string(content)), time.Time{}, content, nil)
+			return
+		}
+
+		g.writeRequest(w, r, output.ETag, output.RenderedAt, buffer.Bytes(), output.Gzip)
+	}, nil
 
 }
 
-func (g Temp) setDynamicRouteHandler(routeProps RouteProps, eTags map[string]string) http.HandlerFunc {
+func (g Temp) setDynamicRouteHandler(routeProps RouteProps, cache *staticCache) (http.HandlerFunc, error) {
 
 	routeFn, err := getDynamicRouteClosure(routeProps)
 
 	if err != nil {
-		panic(fmt.Errorf("Error creating handler for route %s\n%w", routeProps.Path, err))
+		return nil, fmt.Errorf("Error creating handler for route %s\n%w", routeProps.Path, err)
 	}
 
 	return func(w http.ResponseWriter, r *http.Request) {
-		logs := fmt.Sprintf("%s %s %s", r.RemoteAddr, r.Method, r.URL.Path)
-
 		var buffer bytes.Buffer
 
 		routeFn(w, r, g.dependency, &buffer)
 
-		eTag := utils.GenerateETag(buffer.String())
-		writeRequest(w, r, eTag, buffer.Bytes(), eTags, logs)
-	}
+		content := g.config.minifyHTML(routeProps.Path, buffer.Bytes())
+
+		eTag := utils.GenerateETag(string(content))
+		g.writeRequest(w, r, eTag, time.Time{}, content, nil)
+	}, nil
 
 }
 
-func (g Temp) setStaticRouteHandler(routeProps RouteProps, eTags map[string]string) http.HandlerFunc {
+func (g Temp) setStaticRouteHandler(routeProps RouteProps, cache *staticCache) (http.HandlerFunc, error) {
 
-	routeFn, err := getStaticRouteClosure(routeProps)
+	routeFn, err := getStaticRouteClosure(routeProps, cache)
 
 	if err != nil {
-		panic(fmt.Errorf("Error creating handler for route %s\n%w", routeProps.Path, err))
+		return nil, fmt.Errorf("Error creating handler for route %s\n%w", routeProps.Path, err)
 	}
 
+	routeFile := filepath.Join(routeProps.Path, ROUTE_OUT_FILE)
+
 	return func(w http.ResponseWriter, r *http.Request) {
-		logs := fmt.Sprintf("%s %s %s", r.RemoteAddr, r.Method, r.URL.Path)
+		output, err := cache.get(routeFile)
+		if err != nil {
+			g.handleRenderError(err, w, r)
+			return
+		}
+		markCacheHit(r)
+
+		w = replayRecordedResponse(w, output.Response)
+
+		eTag := encodingETag(output.ETag, g.responseEncoding(r, output.Size, output.Gzip))
+		if g.writeNotModified(w, r, eTag, output.RenderedAt) {
+			return
+		}
 
 		var buffer bytes.Buffer
 
 		routeFn(w, r, g.dependency, &buffer)
 
-		eTag := utils.GenerateETag(buffer.String())
-		writeRequest(w, r, eTag, buffer.Bytes(), eTags, logs)
-	}
+		g.writeRequest(w, r, output.ETag, output.RenderedAt, buffer.Bytes(), output.Gzip)
+	}, nil
+
+}
 
+// staticPageFile returns the pre-rendered file served by a static page for the type of @r, "" if it's rendered at runtime
+func staticPageFile(page PageProps, index IndexProps, r *http.Request) string {
+	switch determineRequest(r) {
+	case HxGet_Page, HxBoost_Page:
+		return filepath.Join(page.Path, PAGE_BODY_OUT_FILE_W_METADATA)
+	case HxGet_Index, HxBoost_Index, NormalRequest, HxHistory_Restore, HxBoost_Layout:
+		if index.HandleType == IndexRender {
+			return filepath.Join(page.Path, PAGE_OUT_FILE)
+		}
+	}
+	return ""
 }
 
 func getIndexPropsFromPage(pageProps PageProps) (IndexProps, error) {
@@ -204,23 +457,43 @@ func getIndexPropsFromPage(pageProps PageProps) (IndexProps, error) {
 	return indexProps, nil
 }
 
-func (t *Temp) getETags() map[string]string {
-	eTags := make(map[string]string)
+// replayResponseWriter writes the recorded status of a static file, unless another status is explicitly written e.g. 304
+type replayResponseWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
 
-	file, err := os.Open(filepath.Join(HTML_OUT_DIR, ETAG_FILE))
-	if err != nil {
-		log.Fatalf("Could not create file: %v", err)
+func (rw *replayResponseWriter) WriteHeader(statusCode int) {
+	rw.wroteHeader = true
+	rw.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (rw *replayResponseWriter) Write(bytes []byte) (int, error) {
+	if !rw.wroteHeader {
+		rw.WriteHeader(rw.status)
 	}
-	defer file.Close()
+	return rw.ResponseWriter.Write(bytes)
+}
 
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		parts := strings.Split(scanner.Text(), ":")
-		if len(parts) == 2 {
-			eTags[parts[0]] = parts[1]
+// replayRecordedResponse sets the headers recorded by Render() on @w, wrapping it to replay the recorded status
+func replayRecordedResponse(w http.ResponseWriter, recorded *RecordedResponse) http.ResponseWriter {
+	if recorded == nil {
+		return w
+	}
+
+	for key, values := range recorded.Headers {
+		// replaces the defaults set before the handler ran e.g. by cachePolicy()
+		w.Header().Del(key)
+		for _, value := range values {
+			w.Header().Add(key, value)
 		}
 	}
-	return eTags
+
+	if recorded.Status == 0 || recorded.Status == http.StatusOK {
+		return w
+	}
+	return &replayResponseWriter{ResponseWriter: w, status: recorded.Status}
 }
 
 func formatRequest(r *http.Request, ifPage func(), ifBPage func(), ifIndex func(), ifBIndex func()) {
@@ -234,7 +507,7 @@ func formatRequest(r *http.Request, ifPage func(), ifBPage func(), ifIndex func(
 		ifBPage()
 	case HxGet_Index:
 		ifIndex()
-	case HxBoost_Index, NormalRequest:
+	case HxBoost_Index, NormalRequest, HxHistory_Restore, HxBoost_Layout:
 		ifBIndex()
 	}
 }
@@ -244,6 +517,11 @@ func determineRequest(r *http.Request) requestType {
 		return NormalRequest
 	}
 
+	// htmx missed its history cache, it replaces the whole <body> with the response
+	if utils.IsHxHistoryRestore(r) {
+		return HxHistory_Restore
+	}
+
 	if !utils.IsHxBoosted(r) {
 		if r.URL.Query().Get("index") == "true" {
 			return HxGet_Index
@@ -256,66 +534,144 @@ func determineRequest(r *http.Request) requestType {
 		return ErrorRequest
 	}
 
-	if _, ok := PathToIndex[htmxUrl]; !ok {
+	// both are concrete paths e.g. /examples/hello, matched against the patterns of PathToIndex
+	_, currentIndex, ok := matchIndex(htmxUrl)
+	if !ok {
+		return HxBoost_Index
+	}
+
+	_, index, ok := matchIndex(r.URL.Path)
+	if !ok {
 		return HxBoost_Index
 	}
 
-	if PathToIndex[htmxUrl] == PathToIndex[r.URL.Path] {
+	if index == currentIndex {
 		return HxBoost_Page
 	}
 
+	// only the layouts below the deepest one both pages are rendered in are swapped
+	if _, ok := sharedLayout(currentIndex, index); ok {
+		return HxBoost_Layout
+	}
+
 	return HxBoost_Index
 }
 
-func setBoostHeaders(w http.ResponseWriter) {
-	// soft-set
-	// find { children... } definition
-	w.Header().Set("HX-Retarget", "global main")
-	w.Header().Set("HX-Reswap", "innerHTML transition:true")
-	// the head htmx-extention removes <head> tag from the request!!!
-}
+// setBoostHeaders swaps the page of @r into its index, as declared by the index's Boost var
+func setBoostHeaders(w http.ResponseWriter, r *http.Request) {
+	var index IndexProps
+	if _, indexPath, ok := matchIndex(r.URL.Path); ok {
+		index = Index[indexPath]
+	}
 
-func setPageHeaders(w http.ResponseWriter, eTagPath string, eTags map[string]string) {
-	w.Header().Set("Vary", "HX-Request")
-	w.Header().Set("Cache-Control", "no-cache")
-	w.Header().Set("ETag", eTags[eTagPath])
+	target, swap := index.boost()
+	w.Header().Set("HX-Retarget", target)
+	w.Header().Set("HX-Reswap", swap)
+	// the head htmx-extention removes <head> tag from the request!!!
 }
 
-func setRouteRenderHeaders(w http.ResponseWriter, eTagPath string, eTags map[string]string) {
-	w.Header().Set("Vary", "HX-Request")
-	w.Header().Set("Cache-Control", "no-cache")
-	w.Header().Set("ETag", eTags[eTagPath])
+// boost returns the target & swap of the boosted navigations between the pages of @i, BOOST_RETARGET & BOOST_RESWAP by default
+func (i IndexProps) boost() (string, string) {
+	target, swap := BOOST_RETARGET, BOOST_RESWAP
+	if i.Boost != nil {
+		if i.Boost.Target != "" {
+			target = i.Boost.Target
+		}
+		if i.Boost.Swap != "" {
+			swap = i.Boost.Swap
+		}
+	}
+	return target, swap
 }
 
 func setRouteHeaders(w http.ResponseWriter) {
-	w.Header().Set("Vary", "HX-Request")
-	w.Header().Set("Cache-Control", "no-cache")
+	utils.AddVary(w, VARY_HEADERS...)
+	setDefaultCacheControl(w)
 }
 
+// setHeaders keeps the Vary headers added by the handler e.g. utils.GetHtmxTarget() & the Cache-Control set by cachePolicy()
 func setHeaders(w http.ResponseWriter, eTag string) {
-	w.Header().Set("Vary", "HX-Request")
-	w.Header().Set("Cache-Control", "no-cache")
+	utils.AddVary(w, VARY_HEADERS...)
+	setDefaultCacheControl(w)
 	w.Header().Set("ETag", eTag)
 }
 
-func (t *Temp) handleRenderError(err error, w http.ResponseWriter, logs string) {
+func setDefaultCacheControl(w http.ResponseWriter) {
+	if w.Header().Get("Cache-Control") == "" {
+		w.Header().Set("Cache-Control", DEFAULT_CACHE_CONTROL)
+	}
+}
+
+// cachePolicy sets the Cache-Control header @cacheControl before @handler runs, so it applies to full, partial & boosted responses
+// alike, 304s included. A header set by the handler itself, or recorded by Render(), takes precedence
+func (g Temp) cachePolicy(cacheControl string, handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", cacheControl)
+		handler(w, r)
+	}
+}
+
+func (t *Temp) handleRenderError(err error, w http.ResponseWriter, r *http.Request) {
 	if err != nil {
-		log.Println(fmt.Sprintf("%s %d", logs, http.StatusInternalServerError))
-		w.WriteHeader(http.StatusInternalServerError)
+		t.config.logger().ErrorContext(r.Context(), "render error", "request_id", RequestID(r), "path", r.URL.Path, "error", err)
+		t.writeError(w, r, http.StatusInternalServerError)
 	}
 }
 
-func writeRequest(w http.ResponseWriter, r *http.Request, eTag string, content []byte, eTags map[string]string, logs string) {
-	if rEtag := r.Header.Get("If-None-Match"); rEtag == eTag {
-		log.Println(fmt.Sprintf("%s %d", logs, http.StatusNotModified))
-		w.WriteHeader(http.StatusNotModified)
-		return
+// responseEncoding returns the content encoding of a response of @size bytes, static content is only compressed if it has
+// a pre-compressed @gzipped sibling, dynamic content if larger than Config.GzipMinSize
+func (g Temp) responseEncoding(r *http.Request, size int, gzipped []byte) string {
+	if acceptsGzip(r) && (gzipped != nil || size >= g.config.gzipMinSize()) {
+		return GZIP_ENCODING
+	}
+	return ""
+}
+
+// writeNotModified answers 304 if the client's copy, identified by @eTag or @lastModified, is still valid
+func (g Temp) writeNotModified(w http.ResponseWriter, r *http.Request, eTag string, lastModified time.Time) bool {
+	if !notModified(r, eTag, lastModified) {
+		return false
 	}
-	log.Println(fmt.Sprintf("%s %d", logs, http.StatusOK))
+
 	setHeaders(w, eTag)
+	setLastModified(w, lastModified)
+	w.WriteHeader(http.StatusNotModified)
+	return true
+}
+
+// writeRequest writes @content, gzip compressed if the client accepts it, or 304 if the client's copy is still valid.
+// @lastModified is zero for dynamic content
+func (g Temp) writeRequest(w http.ResponseWriter, r *http.Request, eTag string, lastModified time.Time, content []byte, gzipped []byte) {
+	encoding := g.responseEncoding(r, len(content), gzipped)
+
+	if g.writeNotModified(w, r, encodingETag(eTag, encoding), lastModified) {
+		return
+	}
+
+	if encoding != "" {
+		compressed, err := compressContent(content, gzipped)
+		if err != nil {
+			encoding = ""
+		} else {
+			content = compressed
+			w.Header().Set("Content-Type", HTML_CONTENT_TYPE)
+			w.Header().Set("Content-Encoding", encoding)
+		}
+	}
+
+	setHeaders(w, encodingETag(eTag, encoding))
+	setLastModified(w, lastModified)
 	w.Write(content)
 }
 
+// compressContent returns the pre-compressed @gzipped content of a static file, falling back to compressing @content
+func compressContent(content []byte, gzipped []byte) ([]byte, error) {
+	if gzipped != nil {
+		return gzipped, nil
+	}
+	return gzipBytes(content)
+}
+
 // addMetadataIntoBuffer is used for full-page requests. adds metadata to a new or existing <head></head> tag
 func addMetadataIntoBuffer(buffer *bytes.Buffer, metadata bytes.Buffer) {
 
@@ -5,7 +5,6 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
-	"html/template"
 	"net/http"
 	"path/filepath"
 
@@ -17,7 +16,7 @@ import (
 func getPartialPageBoostFn(partialPageFn func(w http.ResponseWriter, r *http.Request, dep utils.Config, buffer *bytes.Buffer)) func(w http.ResponseWriter, r *http.Request, dep utils.Config, buffer *bytes.Buffer) {
 	return func(w http.ResponseWriter, r *http.Request, dep utils.Config, buffer *bytes.Buffer) {
 		partialPageFn(w, r, dep, buffer)
-		setBoostHeaders(w)
+		setBoostHeaders(w, r)
 	}
 }
 	
@@ -35,25 +34,25 @@ func getDynamicPageClosure(page PageProps, index IndexProps) (func(w http.Respon
 			buffer.Write(mData.Bytes())
 			err := pageFn(w, r, dep).Render(r.Context(), buffer)
 			if err != nil {
-				//set some error stuff
+				panic(fmt.Errorf("Error rendering page.go output from path: %s\n%v", page.Path, err))
 			}
 		},
 		nil
 }
 
 
-func getStaticPageClosure(page PageProps) (func(http.ResponseWriter, *http.Request, utils.Config, *bytes.Buffer), error) {
+func getStaticPageClosure(page PageProps, cache *staticCache) (func(http.ResponseWriter, *http.Request, utils.Config, *bytes.Buffer), error) {
 
-	pageDir := filepath.Clean(filepath.Join(HTML_OUT_DIR, page.Path, PAGE_BODY_OUT_FILE_W_METADATA))
+	pageFile := filepath.Join(page.Path, PAGE_BODY_OUT_FILE_W_METADATA)
 
 	return func(w http.ResponseWriter, r *http.Request, def utils.Config, buffer *bytes.Buffer) {
 
-			pageTpl, err := template.ParseFiles(pageDir)
+			output, err := cache.get(pageFile)
 			if err != nil {
-				panic(fmt.Errorf("Error parsing pre-rendered %s from path: %s\n%v", PAGE_BODY_OUT_FILE_W_METADATA, pageDir, err))
+				panic(fmt.Errorf("Error loading pre-rendered %s of path: %s\n%v", PAGE_BODY_OUT_FILE_W_METADATA, page.Path, err))
 			}
 
-			pageTpl.Execute(buffer, nil)
+			buffer.Write(output.Content)
 
 		},
 		nil
@@ -70,7 +69,7 @@ func getDynamicRouteClosure(route RouteProps) (func(http.ResponseWriter, *http.R
 	return func(w http.ResponseWriter, r *http.Request, dep utils.Config, buffer *bytes.Buffer) {
 			err := routeFn(w, r, dep).Render(r.Context(), buffer)
 			if err != nil {
-				//set some error stuff
+				panic(fmt.Errorf("Error rendering route.go output from path: %s\n%v", route.Path, err))
 			}
 		},
 		nil
@@ -78,28 +77,28 @@ func getDynamicRouteClosure(route RouteProps) (func(http.ResponseWriter, *http.R
 }
 
 			
-func getStaticRouteClosure(route RouteProps) (func(http.ResponseWriter, *http.Request,utils.Config, *bytes.Buffer), error) {
+func getStaticRouteClosure(route RouteProps, cache *staticCache) (func(http.ResponseWriter, *http.Request,utils.Config, *bytes.Buffer), error) {
 
-	pageDir := filepath.Clean(filepath.Join(HTML_OUT_DIR, route.Path, ROUTE_OUT_FILE))
+	routeFile := filepath.Join(route.Path, ROUTE_OUT_FILE)
 
 	return func(w http.ResponseWriter, r *http.Request, dep utils.Config, buffer *bytes.Buffer) {
 
-			pageTpl, err := template.ParseFiles(pageDir)
+			output, err := cache.get(routeFile)
 			if err != nil {
-				panic(fmt.Errorf("Error parsing pre-rendered %s from path: %s\n%v", ROUTE_OUT_FILE, pageDir, err))
+				panic(fmt.Errorf("Error loading pre-rendered %s of path: %s\n%v", ROUTE_OUT_FILE, route.Path, err))
 			}
 
-			pageTpl.Execute(buffer, nil)
+			buffer.Write(output.Content)
 
 		},
 		nil
 }
 
 
-func getStaticFullPageClosure(page PageProps, index IndexProps, indexPath string) (func(http.ResponseWriter, *http.Request, utils.Config, *bytes.Buffer), error) {
+func getStaticFullPageClosure(page PageProps, index IndexProps, indexPath string, cache *staticCache) (func(http.ResponseWriter, *http.Request, utils.Config, *bytes.Buffer), error) {
 
-	fullPageDir := filepath.Clean(filepath.Join(HTML_OUT_DIR, page.Path, PAGE_OUT_FILE))
-	pageDir := filepath.Clean(filepath.Join(HTML_OUT_DIR, page.Path, PAGE_BODY_OUT_FILE))
+	fullPageFile := filepath.Join(page.Path, PAGE_OUT_FILE)
+	pageFile := filepath.Join(page.Path, PAGE_BODY_OUT_FILE)
 
 	switch index.HandleType {
 	case IndexHandle:
@@ -112,14 +111,14 @@ func getStaticFullPageClosure(page PageProps, index IndexProps, indexPath string
 
 		return func(w http.ResponseWriter, r *http.Request, dep utils.Config, buffer *bytes.Buffer) {
 
-			pageTpl, err := template.ParseFiles(pageDir)
+			output, err := cache.get(pageFile)
 			if err != nil {
-				panic(fmt.Errorf("Error parsing pre-rendered %s from path: %s\n%v", PAGE_BODY_OUT_FILE, pageDir, err))
+				panic(fmt.Errorf("Error loading pre-rendered %s of path: %s\n%v", PAGE_BODY_OUT_FILE, page.Path, err))
 			}
 
-			err = indexFn(w, r, dep).Render(templ.WithChildren(r.Context(), templ.FromGoHTML(pageTpl, nil)), buffer)
+			err = indexFn(w, r, dep).Render(templ.WithChildren(r.Context(), output.component()), buffer)
 			if err != nil {
-				//set some error stuff
+				panic(fmt.Errorf("Error rendering index.go output from path: %s\n%v", index.Path, err))
 			}
 
 			addMetadataIntoBuffer(buffer, meta)
@@ -128,12 +127,12 @@ func getStaticFullPageClosure(page PageProps, index IndexProps, indexPath string
 
 	case IndexRender:
 		return func(w http.ResponseWriter, r *http.Request, dep utils.Config, buffer *bytes.Buffer) {
-			fullPageTpl, err := template.ParseFiles(fullPageDir)
+			output, err := cache.get(fullPageFile)
 			if err != nil {
-				panic(fmt.Errorf("Error parsing pre-rendered %s from path: %s\n%v", PAGE_OUT_FILE, pageDir, err))
+				panic(fmt.Errorf("Error loading pre-rendered %s of path: %s\n%v", PAGE_OUT_FILE, page.Path, err))
 			}
 
-			fullPageTpl.Execute(buffer, nil)
+			buffer.Write(output.Content)
 		}, nil
 	}
 
@@ -141,7 +140,7 @@ func getStaticFullPageClosure(page PageProps, index IndexProps, indexPath string
 }		
 
 
-func getDynamicFullPageClosure(page PageProps, index IndexProps, indexPath string) (func(http.ResponseWriter, *http.Request,utils.Config, *bytes.Buffer), error) {
+func getDynamicFullPageClosure(page PageProps, index IndexProps, indexPath string, cache *staticCache) (func(http.ResponseWriter, *http.Request,utils.Config, *bytes.Buffer), error) {
 
 	pageFn := userFunctionWrapper(page.Handler, page.ParamType)
 	if pageFn == nil {
@@ -160,7 +159,7 @@ func getDynamicFullPageClosure(page PageProps, index IndexProps, indexPath strin
 		return func(w http.ResponseWriter, r *http.Request, dep utils.Config, buffer *bytes.Buffer) {
 			err := indexFn(w, r, dep).Render(templ.WithChildren(r.Context(), pageFn(w, r, dep)), buffer)
 			if err != nil {
-				//set some error stuff
+				panic(fmt.Errorf("Error rendering index.go output from path: %s\n%v", index.Path, err))
 			}
 
 			addMetadataIntoBuffer(buffer, meta)
@@ -169,31 +168,20 @@ func getDynamicFullPageClosure(page PageProps, index IndexProps, indexPath strin
 
 	case IndexRender:
 
-		dir := filepath.Clean(filepath.Join(HTML_OUT_DIR, indexPath, INDEX_OUT_FILE))
-
 		return func(w http.ResponseWriter, r *http.Request, dep utils.Config, buffer *bytes.Buffer) {
 
-			indexTpl, err := template.ParseFiles(dir)
-			if err != nil {
-				panic(fmt.Errorf("Error parsing index.html from path: %s\n%v", dir, err))
-			}
-
-			pageTpl, err := templ.ToGoHTML(r.Context(), pageFn(w, r, dep))
-
-			if err != nil {
-				panic(fmt.Errorf("Error converting page.go output from path: %s to template.HTML\n%v", page.Path, err))
-			}
-
-			_, err = indexTpl.New("page").Parse(string(pageTpl))
-
+			layout, err := cache.layout(indexPath)
 			if err != nil {
-				panic(fmt.Errorf("Error converting page.go output from path: %s to template.HTML\n%v", page.Path, err))
+				panic(fmt.Errorf("Error loading pre-rendered %s of path: %s\n%v", INDEX_OUT_FILE, indexPath, err))
 			}
 
-			err = indexTpl.Execute(buffer, nil)
+			// page output is inserted as it is, never parsed as a template
+			err = layout.write(buffer, func(buffer *bytes.Buffer) error {
+				return pageFn(w, r, dep).Render(r.Context(), buffer)
+			})
 
 			if err != nil {
-				panic(fmt.Errorf("Error executing template \n%v", err))
+				panic(fmt.Errorf("Error rendering page.go output from path: %s\n%v", page.Path, err))
 			}
 
 			addMetadataIntoBuffer(buffer, meta)
@@ -244,7 +232,7 @@ func executeAppropriateFn(w http.ResponseWriter, r *http.Request, dep utils.Conf
 		boostPage(w, r, dep, buffer)
 	case HxGet_Index:
 		index(w, r, dep, buffer)
-	case HxBoost_Index, NormalRequest:
+	case HxBoost_Index, NormalRequest, HxHistory_Restore, HxBoost_Layout:
 		boostIndex(w, r, dep, buffer)
 	}
 }
@@ -0,0 +1,163 @@
+package temporary
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"calebsideras.com/temporary/temporary/utils"
+)
+
+// Streaming mode, enabled per dynamic page & route by Config.Stream/StreamPaths, writes the response as it's rendered
+// instead of buffering it:
+//
+//   - the pre-rendered shell of an IndexRender layout, up to the page, is flushed before the page is rendered
+//   - the page or route is then written directly to the http.ResponseWriter
+//   - pages of an IndexHandle index are rendered in full first, as the index has to wrap them
+//   - boosted navigations between nested layouts (HxBoost_Layout) are answered with the full page
+//   - utils.Suspense & utils.SuspenseRoute boundaries are flushed as their skeleton & resolved concurrently, each written
+//     out of order as it resolves, later in the same response (see utils.SuspenseStream), before the rest of the layout
+//   - htmx only swaps a response once complete, so for htmx requests, & writers that can't flush, utils.SuspenseRoute
+//     boundaries are loaded in a round trip instead
+//
+// Streamed responses have no ETag, never answer 304 & aren't compressed or minified (see Config.Minify), as the minifier
+// needs the whole document.
+// An error before anything is flushed answers 500. An error mid-stream writes Config.ErrorComponent into the open response,
+// then aborts it (see recoverPanics) so it's never mistaken for a complete page
+
+// flush sends what has been written so far to the client, if @w supports it
+func flush(w http.ResponseWriter) {
+	err := http.NewResponseController(w).Flush()
+	if err != nil && !errors.Is(err, http.ErrNotSupported) {
+		panic(err)
+	}
+}
+
+// canFlush reports whether flush() sends @w to the client, unwrapping it like http.ResponseController
+func canFlush(w http.ResponseWriter) bool {
+	for {
+		switch rw := w.(type) {
+		case http.Flusher, interface{ FlushError() error }:
+			return true
+		case interface{ Unwrap() http.ResponseWriter }:
+			w = rw.Unwrap()
+		default:
+			return false
+		}
+	}
+}
+
+// waitSuspense writes the suspense boundaries of the response as they resolve, aborting it if the client went away
+func waitSuspense(w http.ResponseWriter, r *http.Request, suspense *utils.SuspenseStream) {
+	err := suspense.Wait(r.Context(), w, func() { flush(w) })
+	if err != nil {
+		panic(fmt.Errorf("Error streaming suspense boundaries from path: %s\n%v", r.URL.Path, err))
+	}
+}
+
+func setStreamHeaders(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", HTML_CONTENT_TYPE)
+	utils.AddVary(w, VARY_HEADERS...)
+	setDefaultCacheControl(w)
+}
+
+func (g Temp) setStreamPageHandler(page PageProps, index IndexProps, cache *staticCache) (http.HandlerFunc, error) {
+
+	pageFn := userFunctionWrapper(page.Handler, page.ParamType)
+	if pageFn == nil {
+		return nil, fmt.Errorf("Error creating handler for route %s\ninvalid handlerParams", page.Path)
+	}
+
+	fullPageFn, err := getDynamicFullPageClosure(page, index, index.Path, cache)
+	if err != nil {
+		return nil, fmt.Errorf("Error creating handler for route %s\n%w", page.Path, err)
+	}
+
+	pageMeta := initPageMetadataVar(append(index.Metadata, page.Metadata...))
+	meta := convertStringListToBytesBuffer(append(index.Metadata, page.Metadata...))
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		requestType := determineRequest(r)
+
+		ctx, suspense := utils.NewSuspenseStream(r, canFlush(w))
+		defer suspense.Close()
+		r = r.WithContext(ctx)
+
+		// written after the page, by IndexRender layouts
+		var tail []byte
+
+		switch requestType {
+		case ErrorRequest:
+			return
+		case HxGet_Page, HxBoost_Page:
+			if requestType == HxBoost_Page {
+				setBoostHeaders(w, r)
+			}
+			setStreamHeaders(w)
+
+			w.Write(pageMeta.Bytes())
+			flush(w)
+		default:
+			setStreamHeaders(w)
+
+			if index.HandleType != IndexRender {
+				var buffer bytes.Buffer
+				fullPageFn(w, r, g.dependency, &buffer)
+				w.Write(buffer.Bytes())
+				flush(w)
+
+				waitSuspense(w, r, suspense)
+				return
+			}
+
+			layout, err := cache.layout(index.Path)
+			if err != nil {
+				panic(fmt.Errorf("Error loading pre-rendered %s of path: %s\n%v", INDEX_OUT_FILE, index.Path, err))
+			}
+
+			// the metadata has to be in the shell, it can't be inserted once flushed
+			var shell bytes.Buffer
+			shell.Write(layout.head)
+			addMetadataIntoBuffer(&shell, meta)
+
+			w.Write(shell.Bytes())
+			flush(w)
+
+			tail = layout.tail
+		}
+
+		err := pageFn(w, r, g.dependency).Render(r.Context(), w)
+		if err != nil {
+			panic(fmt.Errorf("Error rendering page.go output from path: %s\n%v", page.Path, err))
+		}
+		flush(w)
+
+		waitSuspense(w, r, suspense)
+		w.Write(tail)
+	}, nil
+}
+
+func (g Temp) setStreamRouteHandler(routeProps RouteProps) (http.HandlerFunc, error) {
+
+	routeFn := userFunctionWrapper(routeProps.Handler, routeProps.ParamType)
+	if routeFn == nil {
+		return nil, fmt.Errorf("Error creating handler for route %s\ninvalid handlerParams", routeProps.Path)
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		setStreamHeaders(w)
+
+		ctx, suspense := utils.NewSuspenseStream(r, canFlush(w))
+		defer suspense.Close()
+		r = r.WithContext(ctx)
+
+		err := routeFn(w, r, g.dependency).Render(r.Context(), w)
+		if err != nil {
+			panic(fmt.Errorf("Error rendering route.go output from path: %s\n%v", routeProps.Path, err))
+		}
+		flush(w)
+
+		waitSuspense(w, r, suspense)
+	}, nil
+}
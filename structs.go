@@ -2,6 +2,8 @@ package temporary
 
 import (
 	"net/http"
+
+	"calebsideras.com/temporary/temporary/utils"
 )
 
 type HandleType int64
@@ -61,13 +63,16 @@ type RouteProps struct {
 	Path    string
 	Handler interface{}
 	ParamType
+	Cache *utils.Cache // Cache-Control policy, see Config.cacheControl()
 }
 
 type PageProps struct {
 	Path    string
 	Handler interface{}
 	ParamType
-	Metadata []string
+	Metadata     []string
+	StaticParams []map[string]string // slug values a static page is exported with
+	Cache        *utils.Cache        // Cache-Control policy, see Config.cacheControl()
 	// config type
 	// IndexPath string // TODO: soon will be []string?
 }
@@ -78,21 +83,86 @@ type IndexProps struct {
 	ParamType
 	HandleType
 	Metadata []string
+	Boost    *utils.Boost
+	Nested   bool // rendered inside the { children... } of its parent index, see layoutChain()
 }
 
 /**
- * Used for when a HandleFunc is statically rendered but still has w & r params (if these params are used within the func bad stuff will happen)
+ * Used for when a HandleFunc is statically rendered but still has w & r params. The headers, cookies & status set by the
+ * func are recorded, persisted by Render() & replayed by Run() when serving the pre-rendered file
  **/
 
-type DummyResponseWriter struct{}
+type RecordingResponseWriter struct {
+	header http.Header
+	status int
+}
 
-func (d DummyResponseWriter) Header() http.Header {
-	return http.Header{}
+func NewRecordingResponseWriter() *RecordingResponseWriter {
+	return &RecordingResponseWriter{header: http.Header{}}
 }
 
-func (d DummyResponseWriter) Write(bytes []byte) (int, error) {
+func (rw *RecordingResponseWriter) Header() http.Header {
+	return rw.header
+}
+
+// Write discards @bytes, the body of a static page is its rendered templ.Component
+func (rw *RecordingResponseWriter) Write(bytes []byte) (int, error) {
+	if rw.status == 0 {
+		rw.status = http.StatusOK
+	}
 	return len(bytes), nil
 }
 
-func (d DummyResponseWriter) WriteHeader(statusCode int) {
+func (rw *RecordingResponseWriter) WriteHeader(statusCode int) {
+	if rw.status == 0 {
+		rw.status = statusCode
+	}
+}
+
+// Cookies returns the cookies set by the func, these are also recorded as Set-Cookie headers
+func (rw *RecordingResponseWriter) Cookies() []*http.Cookie {
+	return (&http.Response{Header: rw.header}).Cookies()
+}
+
+// Recorded returns the recorded response, nil if the func didn't set anything
+func (rw *RecordingResponseWriter) Recorded() *RecordedResponse {
+	if len(rw.header) == 0 && (rw.status == 0 || rw.status == http.StatusOK) {
+		return nil
+	}
+	return &RecordedResponse{rw.status, rw.header.Clone()}
+}
+
+// RecordedResponse is the part of a response set by a statically rendered HandleFunc
+type RecordedResponse struct {
+	Status  int         `json:"status,omitempty"`
+	Headers http.Header `json:"headers,omitempty"`
+}
+
+// mergeRecordedResponses returns @base with the headers & status of @override taking precedence, e.g. an index & its page.
+// Cookies of both are kept
+func mergeRecordedResponses(base *RecordedResponse, override *RecordedResponse) *RecordedResponse {
+	if base == nil {
+		return override
+	}
+	if override == nil {
+		return base
+	}
+
+	merged := &RecordedResponse{base.Status, base.Headers.Clone()}
+	if merged.Headers == nil {
+		merged.Headers = http.Header{}
+	}
+
+	for key, values := range override.Headers {
+		if key == "Set-Cookie" {
+			merged.Headers[key] = append(merged.Headers[key], values...)
+			continue
+		}
+		merged.Headers[key] = append([]string(nil), values...)
+	}
+
+	if override.Status != 0 {
+		merged.Status = override.Status
+	}
+	return merged
 }
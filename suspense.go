@@ -0,0 +1,92 @@
+package temporary
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"calebsideras.com/temporary/temporary/utils"
+	"github.com/a-h/templ"
+)
+
+// setSuspense serves the suspense boundaries rendered by utils.SuspenseRoute, signed with Config.SuspenseKey,
+// & the runtime boundaries of the pre-rendered files at their stable URL
+func (t *Temp) setSuspense(r Router, cache *staticCache) error {
+	if len(t.config.SuspenseKey) == 0 {
+		t.config.logger().Warn("no SuspenseKey configured, suspense URLs are signed with a random key only valid for this process")
+	}
+
+	err := utils.ConfigureSuspense(t.config.SuspenseKey, t.config.SuspenseTTL)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("   - %s\n", utils.SUSPENSE_PATH)
+
+	err = r.HandlePath(utils.SUSPENSE_PATH, t.logRequests(utils.SUSPENSE_PATH, "dynamic", "suspense", t.recoverPanics(t.suspenseHandler())))
+	if err != nil {
+		return err
+	}
+
+	boundaries, err := cache.suspense()
+	if err != nil {
+		return err
+	}
+
+	for _, boundary := range boundaries {
+		fmt.Printf("   - %s (%s)\n", boundary.URL(), boundary.Name)
+
+		err = r.HandlePath(boundary.URL(), t.logRequests(boundary.URL(), "static", "suspense", t.recoverPanics(t.staticSuspenseHandler(boundary))))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (g Temp) suspenseHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		cmp, err := utils.ResolveSuspense(r)
+		switch {
+		case errors.Is(err, utils.ErrSuspenseExpired):
+			g.writeError(w, r, http.StatusGone)
+			return
+		case errors.Is(err, utils.ErrSuspenseNotFound):
+			g.writeError(w, r, http.StatusNotFound)
+			return
+		case err != nil:
+			g.writeError(w, r, http.StatusForbidden)
+			return
+		}
+
+		g.writeSuspense(w, r, r.URL.Query().Get("c"), cmp)
+	}
+}
+
+// staticSuspenseHandler renders a runtime boundary found in static output by Render()
+func (g Temp) staticSuspenseHandler(boundary utils.SuspenseBoundary) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		cmp, err := boundary.Component(r)
+		if err != nil {
+			// the component isn't registered by this build
+			g.writeError(w, r, http.StatusNotFound)
+			return
+		}
+
+		g.writeSuspense(w, r, boundary.Name, cmp)
+	}
+}
+
+func (g Temp) writeSuspense(w http.ResponseWriter, r *http.Request, name string, cmp templ.Component) {
+	var buffer bytes.Buffer
+
+	err := cmp.Render(r.Context(), &buffer)
+	if err != nil {
+		panic(fmt.Errorf("Error rendering suspense %s\n%v", name, err))
+	}
+
+	content := buffer.Bytes()
+	g.writeRequest(w, r, utils.GenerateETag(string(content)), time.Time{}, content, nil)
+}
@@ -10,6 +10,7 @@ import "calebsideras.com/temporary/src/utils"
 type Temp struct {
 	dependency utils.Config
 	dependencyName string
+	config Config
 }
 
 		
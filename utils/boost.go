@@ -0,0 +1,11 @@
+package utils
+
+// Boost is where a boosted navigation between the pages of an index swaps the new page, declared by its index.go:
+//
+//	var Boost = utils.Boost{Target: "#content", Swap: "innerHTML"}
+type Boost struct {
+	// Target is the HX-Retarget selector of the element rendering the index's children
+	Target string
+	// Swap is the HX-Reswap strategy e.g. "innerHTML transition:true"
+	Swap string
+}
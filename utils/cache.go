@@ -0,0 +1,55 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Cache is the Cache-Control policy of a page or route, declared by its page.go or route.go:
+//
+//	var Cache = utils.Cache{MaxAge: time.Hour, StaleWhileRevalidate: time.Minute}
+//
+// The zero value revalidates every response, i.e. no-cache
+type Cache struct {
+	// MaxAge is how long browsers & shared caches may reuse the response
+	MaxAge time.Duration
+	// SMaxAge overrides MaxAge for shared caches e.g. CDNs
+	SMaxAge time.Duration
+	// StaleWhileRevalidate is how long a stale response may be reused while it's revalidated in the background
+	StaleWhileRevalidate time.Duration
+	// Private keeps the response out of shared caches
+	Private bool
+	// NoStore never stores the response, overriding every other field
+	NoStore bool
+	// Immutable tells browsers the response never changes while fresh, so it isn't revalidated on reload
+	Immutable bool
+}
+
+// String returns the Cache-Control header value of the policy
+func (c Cache) String() string {
+	if c.NoStore {
+		return "no-store"
+	}
+
+	var directives []string
+	if c.Private {
+		directives = append(directives, "private")
+	}
+
+	if c.MaxAge <= 0 && c.SMaxAge <= 0 {
+		return strings.Join(append(directives, "no-cache"), ", ")
+	}
+
+	directives = append(directives, fmt.Sprintf("max-age=%d", int64(c.MaxAge.Seconds())))
+	if c.SMaxAge > 0 {
+		directives = append(directives, fmt.Sprintf("s-maxage=%d", int64(c.SMaxAge.Seconds())))
+	}
+	if c.StaleWhileRevalidate > 0 {
+		directives = append(directives, fmt.Sprintf("stale-while-revalidate=%d", int64(c.StaleWhileRevalidate.Seconds())))
+	}
+	if c.Immutable {
+		directives = append(directives, "immutable")
+	}
+	return strings.Join(directives, ", ")
+}
@@ -0,0 +1,215 @@
+package utils
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+)
+
+// elements whose content is copied as is
+var rawTextElements = map[string]bool{
+	"pre":      true,
+	"textarea": true,
+	"script":   true,
+	"style":    true,
+}
+
+var unquotedAttrValue = regexp.MustCompile("^[^\\s\"'=<>`{}]+$")
+
+// MinifyHTML collapses whitespace outside of <pre>, <textarea>, <script> & <style>, drops comments & attribute quotes that
+// aren't needed. Go template actions e.g. {{ block "page" . }} are copied as is, so the output can still be parsed as one
+func MinifyHTML(content []byte) []byte {
+	var out bytes.Buffer
+	out.Grow(len(content))
+
+	i := 0
+	for i < len(content) {
+		switch {
+		case bytes.HasPrefix(content[i:], []byte("{{")):
+			end := bytes.Index(content[i:], []byte("}}"))
+			if end == -1 {
+				out.Write(content[i:])
+				return out.Bytes()
+			}
+			out.Write(content[i : i+end+2])
+			i += end + 2
+
+		case bytes.HasPrefix(content[i:], []byte("<!--")):
+			end := bytes.Index(content[i:], []byte("-->"))
+			if end == -1 {
+				return out.Bytes()
+			}
+			// conditional comments are kept
+			if bytes.HasPrefix(content[i:], []byte("<!--[if")) {
+				out.Write(content[i : i+end+3])
+			}
+			i += end + 3
+
+		case content[i] == '<' && i+1 < len(content) && (isLetter(content[i+1]) || content[i+1] == '/' || content[i+1] == '!'):
+			end := tagEnd(content, i)
+			if end == -1 {
+				out.Write(content[i:])
+				return out.Bytes()
+			}
+			name := writeMinifiedTag(&out, content[i:end])
+			i = end
+
+			if rawTextElements[name] {
+				closing := indexFold(content[i:], "</"+name)
+				if closing == -1 {
+					out.Write(content[i:])
+					return out.Bytes()
+				}
+				out.Write(content[i : i+closing])
+				i += closing
+			}
+
+		case isSpace(content[i]):
+			for i < len(content) && isSpace(content[i]) {
+				i++
+			}
+			if out.Len() > 0 && i < len(content) && !isSpace(out.Bytes()[out.Len()-1]) {
+				out.WriteByte(' ')
+			}
+
+		default:
+			out.WriteByte(content[i])
+			i++
+		}
+	}
+
+	return out.Bytes()
+}
+
+// tagEnd returns the index after the '>' closing the tag starting at @start, skipping quoted attribute values
+func tagEnd(content []byte, start int) int {
+	var quote byte
+	for i := start + 1; i < len(content); i++ {
+		c := content[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '>':
+			return i + 1
+		}
+	}
+	return -1
+}
+
+// writeMinifiedTag writes @tag with its whitespace collapsed & optional quotes dropped, returns the lowercase name of an opening tag
+func writeMinifiedTag(out *bytes.Buffer, tag []byte) string {
+	if tag[1] == '!' || tag[1] == '/' {
+		out.Write(collapseSpaces(tag))
+		return ""
+	}
+
+	inner := tag[1 : len(tag)-1]
+	selfClosing := bytes.HasSuffix(bytes.TrimRightFunc(inner, isSpaceRune), []byte("/"))
+	if selfClosing {
+		inner = bytes.TrimSuffix(bytes.TrimRightFunc(inner, isSpaceRune), []byte("/"))
+	}
+
+	j := 0
+	for j < len(inner) && !isSpace(inner[j]) {
+		j++
+	}
+	name := string(inner[:j])
+
+	out.WriteByte('<')
+	out.WriteString(name)
+
+	for j < len(inner) {
+		for j < len(inner) && isSpace(inner[j]) {
+			j++
+		}
+		if j >= len(inner) {
+			break
+		}
+
+		// attribute name
+		k := j
+		for k < len(inner) && !isSpace(inner[k]) && inner[k] != '=' {
+			k++
+		}
+		out.WriteByte(' ')
+		out.Write(inner[j:k])
+		j = k
+
+		for j < len(inner) && isSpace(inner[j]) {
+			j++
+		}
+		if j >= len(inner) || inner[j] != '=' {
+			continue
+		}
+		j++
+		for j < len(inner) && isSpace(inner[j]) {
+			j++
+		}
+		if j >= len(inner) {
+			break
+		}
+
+		// attribute value
+		var value []byte
+		quote := inner[j]
+		if quote == '"' || quote == '\'' {
+			end := bytes.IndexByte(inner[j+1:], quote)
+			if end == -1 {
+				end = len(inner) - j - 1
+				value = inner[j+1:]
+				j = len(inner)
+			} else {
+				value = inner[j+1 : j+1+end]
+				j += end + 2
+			}
+		} else {
+			quote = '"'
+			k := j
+			for k < len(inner) && !isSpace(inner[k]) {
+				k++
+			}
+			value = inner[j:k]
+			j = k
+		}
+
+		out.WriteByte('=')
+		if unquotedAttrValue.Match(value) {
+			out.Write(value)
+		} else {
+			out.WriteByte(quote)
+			out.Write(value)
+			out.WriteByte(quote)
+		}
+	}
+
+	if selfClosing {
+		out.WriteString(" /")
+	}
+	out.WriteByte('>')
+
+	return strings.ToLower(name)
+}
+
+func collapseSpaces(b []byte) []byte {
+	return bytes.Join(bytes.Fields(b), []byte(" "))
+}
+
+func indexFold(content []byte, substr string) int {
+	return bytes.Index(bytes.ToLower(content), []byte(substr))
+}
+
+func isLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
+
+func isSpaceRune(r rune) bool {
+	return r < 0x80 && isSpace(byte(r))
+}
@@ -1,59 +1,318 @@
 package utils
 
 import (
-	"bytes"
 	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
 
 	"github.com/a-h/templ"
-	"github.com/google/uuid"
 )
 
-func renderSuspense(childCmp templ.Component) templ.Component {
+// SUSPENSE_PATH serves every suspense boundary, registered by Temp.Handler()
+const SUSPENSE_PATH = "/_temporary/suspense"
 
-	return templ.ComponentFunc(func(ctx context.Context, w io.Writer) (w_Err error) {
-		var w_Buffer bytes.Buffer
+const DEFAULT_SUSPENSE_TTL = time.Hour
 
-		ctx = templ.ClearChildren(ctx)
+var (
+	ErrSuspenseInvalid  = errors.New("invalid suspense signature")
+	ErrSuspenseExpired  = errors.New("suspense boundary expired")
+	ErrSuspenseNotFound = errors.New("suspense component not registered")
+)
+
+// SuspenseHandler renders the deferred content of a suspense boundary from the params it was rendered with
+type SuspenseHandler func(r *http.Request, params map[string]string) templ.Component
+
+var suspense = struct {
+	sync.RWMutex
+	handlers map[string]SuspenseHandler
+	key      []byte
+	ttl      time.Duration
+}{handlers: make(map[string]SuspenseHandler), ttl: DEFAULT_SUSPENSE_TTL}
+
+// RegisterSuspense registers the component @name, rendered by every SuspenseRoute(@name, ...) boundary.
+// Must be registered by every replica, usually from an init()
+func RegisterSuspense(name string, handler SuspenseHandler) {
+	suspense.Lock()
+	defer suspense.Unlock()
+	suspense.handlers[name] = handler
+}
 
-		cmp := childCmp.Render(ctx, &w_Buffer)
-		if cmp != nil {
-			panic(cmp)
+// ConfigureSuspense sets the key signing suspense URLs & how long they are valid for.
+// Replicas behind the same load balancer must share @key, a random one is generated if empty
+func ConfigureSuspense(key []byte, ttl time.Duration) error {
+	if len(key) == 0 {
+		key = make([]byte, 32)
+		_, err := rand.Read(key)
+		if err != nil {
+			return err
 		}
+	}
+	if ttl <= 0 {
+		ttl = DEFAULT_SUSPENSE_TTL
+	}
 
-		_, w_Err = w_Buffer.WriteTo(w)
-		return w_Err
-	})
+	suspense.Lock()
+	defer suspense.Unlock()
+	suspense.key = key
+	suspense.ttl = ttl
+	return nil
 }
 
-func Suspense(skeleton ...templ.Component) templ.Component {
+func signSuspense(key []byte, name string, params string, expires string) string {
+	mac := hmac.New(sha256.New, key)
+	fmt.Fprintf(mac, "%s\n%s\n%s", name, params, expires)
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// SuspenseURL returns the signed URL rendering the registered component @name with @params, valid for the configured ttl
+func SuspenseURL(name string, params map[string]string) (string, error) {
+	suspense.RLock()
+	key, ttl := suspense.key, suspense.ttl
+	suspense.RUnlock()
+
+	if len(key) == 0 {
+		return "", errors.New("suspense is not configured, call ConfigureSuspense() first")
+	}
+
+	encoded, err := json.Marshal(params)
+	if err != nil {
+		return "", err
+	}
+
+	p := base64.RawURLEncoding.EncodeToString(encoded)
+	exp := strconv.FormatInt(time.Now().Add(ttl).Unix(), 10)
+
+	query := url.Values{}
+	query.Set("c", name)
+	query.Set("p", p)
+	query.Set("exp", exp)
+	query.Set("sig", signSuspense(key, name, p, exp))
+
+	return SUSPENSE_PATH + "?" + query.Encode(), nil
+}
+
+// ResolveSuspense verifies the signed URL of @r & returns the component it renders, or the children held by Suspense
+func ResolveSuspense(r *http.Request) (templ.Component, error) {
+	query := r.URL.Query()
+	if key := query.Get("h"); key != "" {
+		return resolveHeldSuspense(key)
+	}
 
-	throwawayURL := fmt.Sprintf("/%s", uuid.New().String())
-	var renderSkeleton templ.Component
+	name, p, exp := query.Get("c"), query.Get("p"), query.Get("exp")
+
+	suspense.RLock()
+	key := suspense.key
+	handler, ok := suspense.handlers[name]
+	suspense.RUnlock()
+
+	sig, err := base64.RawURLEncoding.DecodeString(query.Get("sig"))
+	if err != nil || len(key) == 0 {
+		return nil, ErrSuspenseInvalid
+	}
+
+	expected, _ := base64.RawURLEncoding.DecodeString(signSuspense(key, name, p, exp))
+	if !hmac.Equal(sig, expected) {
+		return nil, ErrSuspenseInvalid
+	}
+
+	expires, err := strconv.ParseInt(exp, 10, 64)
+	if err != nil || time.Now().Unix() > expires {
+		return nil, ErrSuspenseExpired
+	}
+
+	if !ok {
+		return nil, ErrSuspenseNotFound
+	}
+
+	decoded, err := base64.RawURLEncoding.DecodeString(p)
+	if err != nil {
+		return nil, ErrSuspenseInvalid
+	}
+
+	var params map[string]string
+	err = json.Unmarshal(decoded, &params)
+	if err != nil {
+		return nil, ErrSuspenseInvalid
+	}
+
+	return handler(r, params), nil
+}
+
+// SuspenseRoute renders @skeleton, replaced once loaded by the registered component @name rendered with @params.
+// Streamed responses resolve it in place (see NewSuspenseStream), static output as configured by SuspenseOptions.Static,
+// otherwise, including streamed htmx requests, it's loaded from a signed URL so any replica can render it
+func SuspenseRoute(name string, params map[string]string, skeleton ...templ.Component) templ.Component {
+	var opts SuspenseOptions
 	if len(skeleton) > 0 {
-		renderSkeleton = skeleton[0]
+		opts.Skeleton = skeleton[0]
 	}
+	return SuspenseRouteWith(name, params, opts)
+}
+
+// SuspenseRouteWith is SuspenseRoute with the timeout, fallback & static mode of @opts
+func SuspenseRouteWith(name string, params map[string]string, opts SuspenseOptions) templ.Component {
+	boundary := SuspenseBoundary{Name: name, Params: params}
 
-	return templ.ComponentFunc(func(ctx context.Context, w io.Writer) (w_Err error) {
+	return templ.ComponentFunc(func(ctx context.Context, w io.Writer) error {
+		if stream := getSuspenseStream(ctx); stream != nil && !stream.roundTrip {
+			content := templ.ComponentFunc(func(ctx context.Context, w io.Writer) error {
+				cmp, err := boundary.Component(stream.r.WithContext(ctx))
+				if err != nil {
+					return err
+				}
+				return cmp.Render(ctx, w)
+			})
+			return stream.boundary(ctx, w, content, opts)
+		}
+
+		var url string
+		if recorder := getSuspenseRecorder(ctx); recorder != nil {
+			if opts.Static == SuspenseEager {
+				cmp, err := boundary.Component(recorder.r.WithContext(ctx))
+				if err != nil {
+					return fmt.Errorf("suspense %s: %w", name, err)
+				}
+				return cmp.Render(templ.ClearChildren(ctx), w)
+			}
 
+			recorder.record(boundary)
+			url = boundary.URL()
+		} else {
+			var err error
+			url, err = SuspenseURL(name, params)
+			if err != nil {
+				return err
+			}
+		}
+
+		if opts.Skeleton != nil {
+			return StreamComponent(url).Render(templ.WithChildren(ctx, opts.Skeleton), w)
+		}
+		return StreamComponent(url).Render(ctx, w)
+	})
+}
+
+// Suspense renders @skeleton, replaced by its children once resolved in a streamed response (see NewSuspenseStream).
+// Outside stream mode its children are held in memory for the configured ttl by the replica rendering them, as they can't be
+// re-executed elsewhere, & loaded by a follow-up request like SuspenseRoute. Static output renders them in place.
+// A warning is logged the first time a boundary is held
+//
+// Deprecated: outside streamed responses the follow-up request fails if it lands on another replica.
+// Use SuspenseRoute with a component registered by RegisterSuspense
+func Suspense(skeleton ...templ.Component) templ.Component {
+	var opts SuspenseOptions
+	if len(skeleton) > 0 {
+		opts.Skeleton = skeleton[0]
+	}
+	return SuspenseWith(opts)
+}
+
+// SuspenseWith is Suspense with the timeout & fallback of @opts
+func SuspenseWith(opts SuspenseOptions) templ.Component {
+	return templ.ComponentFunc(func(ctx context.Context, w io.Writer) error {
 		childCmp := templ.GetChildren(ctx)
 		if childCmp == nil {
 			childCmp = templ.NopComponent
 		}
 		ctx = templ.ClearChildren(ctx)
 
-		http.HandleFunc(throwawayURL, func(res http.ResponseWriter, req *http.Request) {
-			err := renderSuspense(childCmp).Render(req.Context(), res)
-			if err != nil {
-				panic(err)
-			}
+		if stream := getSuspenseStream(ctx); stream != nil {
+			return stream.boundary(ctx, w, childCmp, opts)
+		}
+
+		if recorder := getSuspenseRecorder(ctx); recorder != nil {
+			return childCmp.Render(ctx, w)
+		}
+
+		warnSuspenseHeld.Do(func() {
+			slog.WarnContext(ctx, "utils.Suspense is deprecated outside stream mode: its children are held in memory & only load from the replica that rendered them. Use utils.SuspenseRoute or enable Config.Stream")
 		})
 
-		if renderSkeleton != nil {
-			return StreamComponent(throwawayURL).Render(templ.WithChildren(ctx, renderSkeleton), w)
+		url, err := holdSuspense(childCmp)
+		if err != nil {
+			return err
+		}
+
+		if opts.Skeleton != nil {
+			return StreamComponent(url).Render(templ.WithChildren(ctx, opts.Skeleton), w)
 		}
-		return StreamComponent(throwawayURL).Render(ctx, w)
+		return StreamComponent(url).Render(ctx, w)
 	})
 }
+
+var warnSuspenseHeld sync.Once
+
+// held are the children of the Suspense boundaries rendered outside stream mode, by id
+var held = struct {
+	sync.Mutex
+	boundaries map[string]heldSuspense
+	pruned     time.Time
+}{boundaries: make(map[string]heldSuspense)}
+
+type heldSuspense struct {
+	content templ.Component
+	expires time.Time
+}
+
+// holdSuspense keeps @content until it expires, returning the URL it's loaded from
+func holdSuspense(content templ.Component) (string, error) {
+	id := make([]byte, 16)
+	_, err := rand.Read(id)
+	if err != nil {
+		return "", err
+	}
+
+	suspense.RLock()
+	ttl := suspense.ttl
+	suspense.RUnlock()
+
+	now := time.Now()
+
+	held.Lock()
+	defer held.Unlock()
+
+	// expired boundaries are never loaded, pruned at most once a minute
+	if now.Sub(held.pruned) > time.Minute {
+		for key, boundary := range held.boundaries {
+			if now.After(boundary.expires) {
+				delete(held.boundaries, key)
+			}
+		}
+		held.pruned = now
+	}
+
+	key := base64.RawURLEncoding.EncodeToString(id)
+	held.boundaries[key] = heldSuspense{content, now.Add(ttl)}
+
+	query := url.Values{}
+	query.Set("h", key)
+	return SUSPENSE_PATH + "?" + query.Encode(), nil
+}
+
+// resolveHeldSuspense returns the held children @key
+func resolveHeldSuspense(key string) (templ.Component, error) {
+	held.Lock()
+	defer held.Unlock()
+
+	boundary, ok := held.boundaries[key]
+	if !ok {
+		return nil, ErrSuspenseNotFound
+	}
+	if time.Now().After(boundary.expires) {
+		delete(held.boundaries, key)
+		return nil, ErrSuspenseExpired
+	}
+	return boundary.content, nil
+}
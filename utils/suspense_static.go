@@ -0,0 +1,101 @@
+package utils
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+
+	"github.com/a-h/templ"
+)
+
+// SuspenseMode is how Render() writes a SuspenseRoute boundary into static output, where a signed URL would expire
+type SuspenseMode int
+
+const (
+	// SuspenseRuntime points the boundary at a stable endpoint registered by Run(), resolved on every load
+	SuspenseRuntime SuspenseMode = iota
+	// SuspenseEager resolves the boundary at build time, into the static HTML
+	SuspenseEager
+)
+
+// SuspenseBoundary is a SuspenseRoute boundary found by Render() in static output, served by Run() at its URL()
+type SuspenseBoundary struct {
+	Name   string            `json:"name"`
+	Params map[string]string `json:"params,omitempty"`
+}
+
+// ID is derived from the name & params only, so it's the same on every render & replica
+func (b SuspenseBoundary) ID() string {
+	params, _ := json.Marshal(b.Params)
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s\n%s", b.Name, params)))
+	return hex.EncodeToString(sum[:12])
+}
+
+func (b SuspenseBoundary) URL() string {
+	return SUSPENSE_PATH + "/" + b.ID()
+}
+
+// Component returns the registered component rendering the boundary
+func (b SuspenseBoundary) Component(r *http.Request) (templ.Component, error) {
+	suspense.RLock()
+	handler, ok := suspense.handlers[b.Name]
+	suspense.RUnlock()
+
+	if !ok {
+		return nil, ErrSuspenseNotFound
+	}
+	return handler(r, b.Params), nil
+}
+
+// SuspenseRecorder collects the boundaries of static output rendered by Render()
+type SuspenseRecorder struct {
+	r          *http.Request
+	mu         sync.Mutex
+	boundaries map[string]SuspenseBoundary
+}
+
+type suspenseRecorderKey struct{}
+
+// NewSuspenseRecorder returns a context rendering boundaries as static output, eager boundaries are resolved with @r
+func NewSuspenseRecorder(ctx context.Context, r *http.Request) (context.Context, *SuspenseRecorder) {
+	recorder := &SuspenseRecorder{r: r, boundaries: make(map[string]SuspenseBoundary)}
+	return context.WithValue(ctx, suspenseRecorderKey{}, recorder), recorder
+}
+
+func getSuspenseRecorder(ctx context.Context) *SuspenseRecorder {
+	recorder, _ := ctx.Value(suspenseRecorderKey{}).(*SuspenseRecorder)
+	return recorder
+}
+
+func (s *SuspenseRecorder) record(boundary SuspenseBoundary) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.boundaries[boundary.ID()] = boundary
+}
+
+// Boundaries returns the runtime boundaries rendered so far, sorted by ID
+func (s *SuspenseRecorder) Boundaries() []SuspenseBoundary {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.boundaries) == 0 {
+		return nil
+	}
+
+	ids := make([]string, 0, len(s.boundaries))
+	for id := range s.boundaries {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	boundaries := make([]SuspenseBoundary, 0, len(ids))
+	for _, id := range ids {
+		boundaries = append(boundaries, s.boundaries[id])
+	}
+	return boundaries
+}
@@ -0,0 +1,218 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/a-h/templ"
+)
+
+const DEFAULT_SUSPENSE_TIMEOUT = 10 * time.Second
+
+// SuspenseOptions configures a single suspense boundary
+type SuspenseOptions struct {
+	// Skeleton is rendered until the boundary is resolved
+	Skeleton templ.Component
+	// Timeout is how long a streamed boundary may take to resolve, defaults to DEFAULT_SUSPENSE_TIMEOUT
+	Timeout time.Duration
+	// Fallback replaces the skeleton if a streamed boundary times out or fails, defaults to DefaultSuspenseFallback
+	Fallback templ.Component
+	// Static is how Render() writes a SuspenseRoute boundary into static output, defaults to SuspenseRuntime
+	Static SuspenseMode
+}
+
+func (o SuspenseOptions) timeout() time.Duration {
+	if o.Timeout > 0 {
+		return o.Timeout
+	}
+	return DEFAULT_SUSPENSE_TIMEOUT
+}
+
+func (o SuspenseOptions) fallback() templ.Component {
+	if o.Fallback != nil {
+		return o.Fallback
+	}
+	return DefaultSuspenseFallback
+}
+
+var DefaultSuspenseFallback = templ.ComponentFunc(func(ctx context.Context, w io.Writer) error {
+	_, err := io.WriteString(w, `<div class="temporary-suspense-error">Failed to load</div>`)
+	return err
+})
+
+// replaces the skeleton by the resolved <template> for documents, which don't process hx-swap-oob, letting htmx process the new content if loaded
+const suspenseSwapScript = `(function(t,e){if(e){var p=e.parentElement;e.replaceWith(t.content.cloneNode(true));if(window.htmx&&p)htmx.process(p)}t.remove()})(document.getElementById("%s-content"),document.getElementById("%s"))`
+
+type suspenseResult struct {
+	id      string
+	content []byte
+	err     error
+}
+
+// SuspenseStream resolves the suspense boundaries of a single streamed response concurrently.
+// Each boundary renders its skeleton in place, the resolved content is written out of order by Wait()
+type SuspenseStream struct {
+	r         *http.Request
+	htmx      bool
+	roundTrip bool
+	prefix    string
+	mu        sync.Mutex
+	next      int
+	pending   int
+	fallbacks map[string]templ.Component
+	results   chan suspenseResult
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+type suspenseStreamKey struct{}
+
+// NewSuspenseStream returns the context of @r streaming the suspense boundaries rendered with it, instead of loading them in a round trip.
+// @flushable is whether the response can be flushed. htmx only swaps a response once complete, so for htmx requests & responses
+// that can't be flushed SuspenseRoute boundaries are still loaded in a round trip, rather than holding back the whole response
+func NewSuspenseStream(r *http.Request, flushable bool) (context.Context, *SuspenseStream) {
+	prefix := make([]byte, 4)
+	rand.Read(prefix)
+
+	htmx := IsHtmxRequest(r)
+
+	stream := &SuspenseStream{
+		r:         r,
+		htmx:      htmx,
+		roundTrip: htmx || !flushable,
+		prefix:    "temporary-suspense-" + hex.EncodeToString(prefix),
+		fallbacks: make(map[string]templ.Component),
+		results:   make(chan suspenseResult),
+		done:      make(chan struct{}),
+	}
+	return context.WithValue(r.Context(), suspenseStreamKey{}, stream), stream
+}
+
+func getSuspenseStream(ctx context.Context) *SuspenseStream {
+	stream, _ := ctx.Value(suspenseStreamKey{}).(*SuspenseStream)
+	return stream
+}
+
+// boundary writes the skeleton of a boundary & resolves @content in the background, within the boundary's timeout
+func (s *SuspenseStream) boundary(ctx context.Context, w io.Writer, content templ.Component, opts SuspenseOptions) error {
+	s.mu.Lock()
+	s.next++
+	s.pending++
+	id := fmt.Sprintf("%s-%d", s.prefix, s.next)
+	s.fallbacks[id] = opts.fallback()
+	s.mu.Unlock()
+
+	// nested boundaries aren't streamed, they're resolved as part of this one
+	resolveCtx, cancel := context.WithTimeout(context.WithValue(templ.ClearChildren(ctx), suspenseStreamKey{}, (*SuspenseStream)(nil)), opts.timeout())
+
+	go func() {
+		defer cancel()
+
+		var buffer bytes.Buffer
+		rendered := make(chan error, 1)
+		go func() {
+			defer func() {
+				if rec := recover(); rec != nil {
+					rendered <- fmt.Errorf("suspense boundary panic: %v", rec)
+				}
+			}()
+			rendered <- content.Render(resolveCtx, &buffer)
+		}()
+
+		result := suspenseResult{id: id}
+		select {
+		case result.err = <-rendered:
+			result.content = buffer.Bytes()
+		case <-resolveCtx.Done():
+			result.err = resolveCtx.Err()
+		}
+
+		select {
+		case s.results <- result:
+		case <-s.done:
+		}
+	}()
+
+	_, err := fmt.Fprintf(w, `<div id="%s">`, id)
+	if err != nil {
+		return err
+	}
+	if opts.Skeleton != nil {
+		err = opts.Skeleton.Render(templ.ClearChildren(ctx), w)
+		if err != nil {
+			return err
+		}
+	}
+	_, err = io.WriteString(w, `</div>`)
+	return err
+}
+
+// Close releases the boundaries still resolving, which are never written. Must be called once the response is over,
+// even if Wait() wasn't e.g. after a panic
+func (s *SuspenseStream) Close() {
+	s.closeOnce.Do(func() {
+		close(s.done)
+	})
+}
+
+// Wait writes every boundary once resolved, in the order they resolve, calling @flush after each.
+// A boundary that failed or timed out is replaced by its fallback
+func (s *SuspenseStream) Wait(ctx context.Context, w io.Writer, flush func()) error {
+	defer s.Close()
+
+	for {
+		s.mu.Lock()
+		pending := s.pending
+		s.mu.Unlock()
+
+		if pending == 0 {
+			return nil
+		}
+
+		var result suspenseResult
+		select {
+		case result = <-s.results:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		s.mu.Lock()
+		s.pending--
+		fallback := s.fallbacks[result.id]
+		s.mu.Unlock()
+
+		content := result.content
+		if result.err != nil {
+			var buffer bytes.Buffer
+			err := fallback.Render(ctx, &buffer)
+			if err != nil {
+				return err
+			}
+			content = buffer.Bytes()
+		}
+
+		err := s.writeResolved(w, result.id, content)
+		if err != nil {
+			return err
+		}
+		flush()
+	}
+}
+
+// writeResolved writes @content as an out-of-band swap of the boundary @id for htmx requests, or a <template> swapped in by script for documents
+func (s *SuspenseStream) writeResolved(w io.Writer, id string, content []byte) error {
+	var err error
+	if s.htmx {
+		_, err = fmt.Fprintf(w, `<div id="%s" hx-swap-oob="outerHTML">%s</div>`, id, content)
+	} else {
+		_, err = fmt.Fprintf(w, `<template id="%s-content">%s</template><script>`+suspenseSwapScript+`</script>`, id, content, id, id)
+	}
+	return err
+}
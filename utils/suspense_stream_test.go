@@ -0,0 +1,219 @@
+package utils
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/a-h/templ"
+)
+
+func renderBoundaries(t *testing.T, ctx context.Context, n int) {
+	content := templ.ComponentFunc(func(ctx context.Context, w io.Writer) error {
+		_, err := io.WriteString(w, "resolved")
+		return err
+	})
+
+	for i := 0; i < n; i++ {
+		err := SuspenseWith(SuspenseOptions{}).Render(templ.WithChildren(ctx, content), io.Discard)
+		if err != nil {
+			t.Fatalf("Render() error = %v", err)
+		}
+	}
+}
+
+// waitGoroutines waits for the number of goroutines to drop back to @want, as exiting ones aren't counted out immediately
+func waitGoroutines(t *testing.T, want int) {
+	deadline := time.Now().Add(2 * time.Second)
+	for runtime.NumGoroutine() > want {
+		if time.Now().After(deadline) {
+			t.Fatalf("%d goroutines still running, want %d", runtime.NumGoroutine(), want)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestSuspenseStreamCloseWithoutWait(t *testing.T) {
+	before := runtime.NumGoroutine()
+
+	ctx, stream := NewSuspenseStream(httptest.NewRequest("GET", "/", nil), true)
+	renderBoundaries(t, ctx, 50)
+
+	// e.g. the page panicked after rendering its boundaries, before Wait()
+	stream.Close()
+
+	waitGoroutines(t, before)
+}
+
+func TestSuspenseStreamCloseAfterWait(t *testing.T) {
+	before := runtime.NumGoroutine()
+
+	ctx, stream := NewSuspenseStream(httptest.NewRequest("GET", "/", nil), true)
+	renderBoundaries(t, ctx, 5)
+
+	err := stream.Wait(context.Background(), io.Discard, func() {})
+	if err != nil {
+		t.Fatalf("Wait() error = %v", err)
+	}
+	stream.Close()
+
+	waitGoroutines(t, before)
+}
+
+func TestSuspenseStreamRoundTrip(t *testing.T) {
+	err := ConfigureSuspense([]byte("test-key"), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	RegisterSuspense("round-trip", func(r *http.Request, params map[string]string) templ.Component {
+		return templ.NopComponent
+	})
+
+	htmx := httptest.NewRequest("GET", "/", nil)
+	htmx.Header.Set("HX-Request", "true")
+
+	tests := []struct {
+		name      string
+		r         *http.Request
+		flushable bool
+		roundTrip bool
+	}{
+		{"document", httptest.NewRequest("GET", "/", nil), true, false},
+		{"htmx", htmx, true, true},
+		{"unflushable", httptest.NewRequest("GET", "/", nil), false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, stream := NewSuspenseStream(tt.r, tt.flushable)
+			defer stream.Close()
+
+			var buffer strings.Builder
+			err := SuspenseRoute("round-trip", nil).Render(ctx, &buffer)
+			if err != nil {
+				t.Fatalf("Render() error = %v", err)
+			}
+
+			if got := strings.Contains(buffer.String(), SUSPENSE_PATH); got != tt.roundTrip {
+				t.Fatalf("round trip = %v, want %v: %s", got, tt.roundTrip, buffer.String())
+			}
+		})
+	}
+}
+
+func TestSuspenseHeldOutsideStream(t *testing.T) {
+	var buffer strings.Builder
+	ctx := templ.WithChildren(context.Background(), templ.ComponentFunc(func(ctx context.Context, w io.Writer) error {
+		_, err := io.WriteString(w, "children")
+		return err
+	}))
+
+	err := Suspense().Render(ctx, &buffer)
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if strings.Contains(buffer.String(), "children") || !strings.Contains(buffer.String(), SUSPENSE_PATH) {
+		t.Fatalf("Render() = %q, want a boundary loading its children", buffer.String())
+	}
+}
+
+// delayed renders @content after @delay, or fails once @ctx is done
+func delayed(delay time.Duration, content string) templ.Component {
+	return templ.ComponentFunc(func(ctx context.Context, w io.Writer) error {
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		_, err := io.WriteString(w, content)
+		return err
+	})
+}
+
+// streamBoundaries renders a boundary per component of @children with @opts, then waits for all of them
+func streamBoundaries(t *testing.T, r *http.Request, opts SuspenseOptions, children ...templ.Component) (string, string) {
+	ctx, stream := NewSuspenseStream(r, true)
+	defer stream.Close()
+
+	var page strings.Builder
+	for _, child := range children {
+		err := SuspenseWith(opts).Render(templ.WithChildren(ctx, child), &page)
+		if err != nil {
+			t.Fatalf("Render() error = %v", err)
+		}
+	}
+
+	var resolved strings.Builder
+	err := stream.Wait(context.Background(), &resolved, func() {})
+	if err != nil {
+		t.Fatalf("Wait() error = %v", err)
+	}
+	return page.String(), resolved.String()
+}
+
+func TestSuspenseStreamOutOfOrder(t *testing.T) {
+	opts := SuspenseOptions{Skeleton: templ.ComponentFunc(func(ctx context.Context, w io.Writer) error {
+		_, err := io.WriteString(w, "loading")
+		return err
+	})}
+
+	page, resolved := streamBoundaries(t, httptest.NewRequest("GET", "/", nil), opts,
+		delayed(200*time.Millisecond, "slow"),
+		delayed(10*time.Millisecond, "fast"),
+	)
+
+	if strings.Count(page, "loading") != 2 {
+		t.Fatalf("page = %q, want a skeleton per boundary", page)
+	}
+
+	slow, fast := strings.Index(resolved, "slow"), strings.Index(resolved, "fast")
+	if slow < 0 || fast < 0 || fast > slow {
+		t.Fatalf("resolved = %q, want the fast boundary before the slow one", resolved)
+	}
+}
+
+func TestSuspenseStreamTimeoutFallback(t *testing.T) {
+	fallback := templ.ComponentFunc(func(ctx context.Context, w io.Writer) error {
+		_, err := io.WriteString(w, "custom fallback")
+		return err
+	})
+
+	tests := []struct {
+		name string
+		opts SuspenseOptions
+		want string
+	}{
+		{"default", SuspenseOptions{Timeout: 50 * time.Millisecond}, "Failed to load"},
+		{"custom", SuspenseOptions{Timeout: 50 * time.Millisecond, Fallback: fallback}, "custom fallback"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, resolved := streamBoundaries(t, httptest.NewRequest("GET", "/", nil), tt.opts, delayed(time.Second, "loaded"))
+
+			if strings.Contains(resolved, "loaded") || !strings.Contains(resolved, tt.want) {
+				t.Fatalf("resolved = %q, want the %s fallback", resolved, tt.name)
+			}
+		})
+	}
+}
+
+func TestSuspenseStreamResolvedFormat(t *testing.T) {
+	htmx := httptest.NewRequest("GET", "/", nil)
+	htmx.Header.Set("HX-Request", "true")
+
+	_, resolved := streamBoundaries(t, htmx, SuspenseOptions{}, delayed(0, "content"))
+	if !strings.Contains(resolved, `hx-swap-oob="outerHTML">content</div>`) || strings.Contains(resolved, "<script>") {
+		t.Fatalf("htmx resolved = %q, want an out-of-band swap", resolved)
+	}
+
+	_, resolved = streamBoundaries(t, httptest.NewRequest("GET", "/", nil), SuspenseOptions{}, delayed(0, "content"))
+	if !strings.Contains(resolved, "<template") || !strings.Contains(resolved, "<script>") || strings.Contains(resolved, "hx-swap-oob") {
+		t.Fatalf("document resolved = %q, want a <template> swapped in by script", resolved)
+	}
+}
@@ -112,6 +112,41 @@ func IsHxBoosted(r *http.Request) bool {
 	}
 }
 
+// IsHxHistoryRestore reports whether htmx missed its history cache & is restoring the page, which needs the full page
+func IsHxHistoryRestore(r *http.Request) bool {
+	return r.Header.Get("HX-History-Restore-Request") == "true"
+}
+
+// GetHtmxTarget returns the id of the target element of @r, adding HX-Target to the Vary header of @w as the response depends on it
+func GetHtmxTarget(w http.ResponseWriter, r *http.Request) string {
+	AddVary(w, "HX-Target")
+	return r.Header.Get("HX-Target")
+}
+
+// GetHtmxTrigger returns the id of the element that triggered @r, adding HX-Trigger to the Vary header of @w as the response depends on it
+func GetHtmxTrigger(w http.ResponseWriter, r *http.Request) string {
+	AddVary(w, "HX-Trigger")
+	return r.Header.Get("HX-Trigger")
+}
+
+// AddVary adds the request @headers the response depends on to the Vary header of @w, skipping those already listed
+func AddVary(w http.ResponseWriter, headers ...string) {
+	var vary []string
+	listed := NewStringSet()
+	for _, value := range append(w.Header().Values("Vary"), headers...) {
+		for _, header := range strings.Split(value, ",") {
+			header = strings.TrimSpace(header)
+			if header == "" || listed.Contains(http.CanonicalHeaderKey(header)) {
+				continue
+			}
+			listed.Add(http.CanonicalHeaderKey(header))
+			vary = append(vary, header)
+		}
+	}
+
+	w.Header().Set("Vary", strings.Join(vary, ", "))
+}
+
 func LastElementOfURL(rawURL string) (string, error) {
 	u, err := url.Parse(rawURL)
 	if err != nil {